@@ -0,0 +1,146 @@
+package yxyiot
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/bigrocs/yxyiot/ratelimit"
+	"github.com/bigrocs/yxyiot/requests"
+	"github.com/bigrocs/yxyiot/responses"
+)
+
+// ErrBatchStopped 设置 StopOnError 时因其他设备失败而未发送
+var ErrBatchStopped = errors.New("yxyiot: batch stopped after device failure")
+
+// DefaultBatchConcurrency 批量播报默认并发数
+const DefaultBatchConcurrency = 8
+
+// BatchOptions 批量播报选项
+type BatchOptions struct {
+	Concurrency int     // 并发数，默认 DefaultBatchConcurrency
+	Rate        float64 // 整批每秒最多发送的请求数，小于等于 0 时仅受客户端限流约束
+	StopOnError bool    // 任一设备失败后停止发送剩余设备
+}
+
+// DeviceResult 单台设备的播报结果
+type DeviceResult struct {
+	DevName  string
+	Outcome  string // 调用结果，见 Outcome
+	Response *responses.CommonResponse
+	Err      error // 失败原因，业务错误为 *responses.APIError；未发送的设备为上下文错误或 ErrBatchStopped
+}
+
+// BatchResult 批量播报结果，Results 与传入设备顺序一致
+type BatchResult struct {
+	Results   []DeviceResult
+	Succeeded int
+	Failed    int
+}
+
+// Failures 返回失败的设备结果
+func (r *BatchResult) Failures() []DeviceResult {
+	var list []DeviceResult
+	for _, res := range r.Results {
+		if res.Outcome != OutcomeSuccess {
+			list = append(list, res)
+		}
+	}
+	return list
+}
+
+// PlayBatch 向多台设备并发发送相同的播报内容
+// 请求携带 RequestId、BizKey 时每台设备分别使用 "<RequestId>-<devName>"、"<BizKey>-<devName>"，保证重复提交时可去重
+// 上下文取消后未发送的设备以上下文错误记录，返回的 error 仅表示批次被取消
+func (client *Client) PlayBatch(ctx context.Context, devices []string, request requests.PlayRequest, opts *BatchOptions) (*BatchResult, error) {
+	if opts == nil {
+		opts = &BatchOptions{}
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	var limiter *ratelimit.Limiter
+	if opts.Rate > 0 {
+		limiter = ratelimit.New(ratelimit.Limit{Rate: opts.Rate, Burst: concurrency}, ratelimit.Limit{}, true)
+	}
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	skipped := func(devName string) DeviceResult {
+		err := parent.Err()
+		if err == nil {
+			err = ErrBatchStopped
+		}
+		return DeviceResult{DevName: devName, Outcome: OutcomeError, Err: err}
+	}
+
+	result := &BatchResult{Results: make([]DeviceResult, len(devices))}
+	runBounded(ctx, len(devices), concurrency, func(i int) {
+		result.Results[i] = client.playDevice(ctx, limiter, devices[i], request)
+		if opts.StopOnError && result.Results[i].Outcome != OutcomeSuccess {
+			cancel()
+		}
+	}, func(i int) {
+		result.Results[i] = skipped(devices[i])
+	})
+
+	for _, res := range result.Results {
+		if res.Outcome == OutcomeSuccess {
+			result.Succeeded++
+		} else {
+			result.Failed++
+		}
+	}
+	return result, parent.Err()
+}
+
+// runBounded 以最多 concurrency 个协程对下标 0 至 n-1 依次执行 run，结束后返回
+// 上下文取消后尚未执行的下标改为执行 skip
+func runBounded(ctx context.Context, n, concurrency int, run, skip func(i int)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				run(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		if ctx.Err() != nil {
+			skip(i)
+			continue
+		}
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			skip(i)
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// playDevice 向单台设备播报
+func (client *Client) playDevice(ctx context.Context, limiter *ratelimit.Limiter, devName string, request requests.PlayRequest) DeviceResult {
+	res := DeviceResult{DevName: devName}
+	if limiter != nil {
+		if err := limiter.Take(ctx, "batch", ""); err != nil {
+			res.Outcome, res.Err = OutcomeError, err
+			return res
+		}
+	}
+	request.DevName = devName
+	if request.RequestId != "" {
+		request.RequestId += "-" + devName
+	}
+	if request.BizKey != "" {
+		request.BizKey += "-" + devName
+	}
+	res.Response, res.Err = client.Play(ctx, &request)
+	res.Outcome = Outcome(res.Response, res.Err)
+	return res
+}
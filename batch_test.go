@@ -0,0 +1,39 @@
+package yxyiot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bigrocs/yxyiot/requests"
+	"github.com/bigrocs/yxyiot/responses"
+)
+
+// fakeTransport 以拦截器替代真实请求，按设备返回预设内容
+func fakeTransport(replies map[string]string) Interceptor {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request *requests.CommonRequest, response *responses.CommonResponse) error {
+			reply, ok := replies[request.BizContent["devName"].(string)]
+			if !ok {
+				reply = `{"code":0,"msg":"success"}`
+			}
+			response.SetHttpContent([]byte(reply), "string")
+			return nil
+		}
+	}
+}
+
+func TestPlayBatch(t *testing.T) {
+	client := NewClient()
+	client.Use(fakeTransport(map[string]string{"bsj00002": `{"code":1001,"msg":"device offline"}`}))
+	devices := []string{"bsj00001", "bsj00002", "bsj00003"}
+	res, err := client.PlayBatch(context.Background(), devices, *requests.NewPlayRequest("", "周年庆全场八折"), &BatchOptions{Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Succeeded != 2 || res.Failed != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if f := res.Failures(); len(f) != 1 || f[0].DevName != "bsj00002" || f[0].Outcome != OutcomeAPIError {
+		t.Fatalf("unexpected failures: %+v", f)
+	}
+}
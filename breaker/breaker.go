@@ -0,0 +1,220 @@
+package breaker
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 熔断对象类型
+const (
+	KindEndpoint = "endpoint" // 接口地址
+	KindDevice   = "device"   // 设备 devName
+)
+
+// Key 熔断对象
+type Key struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+func (k Key) String() string { return k.Kind + ":" + k.Name }
+
+// State 熔断状态
+type State int
+
+const (
+	Closed   State = iota // 正常放行
+	Open                  // 熔断中，直接拒绝
+	HalfOpen              // 冷却结束，放行少量探测请求
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// MarshalText 以文本形式输出状态
+func (s State) MarshalText() ([]byte, error) { return []byte(s.String()), nil }
+
+// OpenError 熔断器处于打开状态
+type OpenError struct {
+	Key   Key
+	Until time.Time // 预计进入半开状态的时间
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("yxyiot: circuit open, %s until %s", e.Key, e.Until.Format(time.RFC3339))
+}
+
+// Settings 熔断配置
+type Settings struct {
+	Threshold        int                           // 连续失败多少次后熔断，默认 5
+	Cooldown         time.Duration                 // 熔断持续时间，默认 30 秒
+	HalfOpenRequests int                           // 半开状态下允许的并发探测数，默认 1
+	OnStateChange    func(key Key, from, to State) // 状态变化回调
+}
+
+// Status 熔断对象状态
+type Status struct {
+	Key       Key       `json:"key"`
+	State     State     `json:"state"`
+	Failures  int       `json:"failures"`  // 连续失败次数
+	OpenedAt  time.Time `json:"openedAt"`  // 最近一次熔断时间
+	LastError string    `json:"lastError"` // 最近一次失败原因
+}
+
+// circuit 单个熔断对象
+type circuit struct {
+	Status
+	probes int
+}
+
+// Breaker 按接口地址及设备分别熔断
+type Breaker struct {
+	settings Settings
+	mu       sync.Mutex
+	circuits map[Key]*circuit
+}
+
+// New 创建熔断器
+func New(settings Settings) *Breaker {
+	if settings.Threshold <= 0 {
+		settings.Threshold = 5
+	}
+	if settings.Cooldown <= 0 {
+		settings.Cooldown = 30 * time.Second
+	}
+	if settings.HalfOpenRequests <= 0 {
+		settings.HalfOpenRequests = 1
+	}
+	return &Breaker{settings: settings, circuits: make(map[Key]*circuit)}
+}
+
+// circuit 获取熔断对象，调用方需持有锁
+func (b *Breaker) circuit(key Key) *circuit {
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{Status: Status{Key: key}}
+		b.circuits[key] = c
+	}
+	return c
+}
+
+// setState 切换状态，调用方需持有锁
+func (b *Breaker) setState(c *circuit, to State) {
+	from := c.State
+	if from == to {
+		return
+	}
+	c.State = to
+	c.probes = 0
+	if to == Open {
+		c.OpenedAt = time.Now()
+	}
+	if b.settings.OnStateChange != nil {
+		go b.settings.OnStateChange(c.Key, from, to)
+	}
+}
+
+// Allow 检查请求是否放行，任一对象熔断时返回 *OpenError 且不占用探测名额
+func (b *Breaker) Allow(keys ...Key) error {
+	now := time.Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, key := range keys {
+		c := b.circuit(key)
+		switch c.State {
+		case Open:
+			until := c.OpenedAt.Add(b.settings.Cooldown)
+			if now.Before(until) {
+				return &OpenError{Key: key, Until: until}
+			}
+		case HalfOpen:
+			if c.probes >= b.settings.HalfOpenRequests {
+				return &OpenError{Key: key, Until: now.Add(b.settings.Cooldown)}
+			}
+		}
+	}
+	for _, key := range keys {
+		c := b.circuit(key)
+		if c.State == Open {
+			b.setState(c, HalfOpen)
+		}
+		if c.State == HalfOpen {
+			c.probes++
+		}
+	}
+	return nil
+}
+
+// Success 记录请求成功
+func (b *Breaker) Success(key Key) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuit(key)
+	c.Failures = 0
+	b.setState(c, Closed)
+}
+
+// Failure 记录请求失败
+func (b *Breaker) Failure(key Key, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuit(key)
+	c.Failures++
+	if err != nil {
+		c.LastError = err.Error()
+	}
+	if c.State == HalfOpen || c.Failures >= b.settings.Threshold {
+		b.setState(c, Open)
+		c.OpenedAt = time.Now()
+	}
+}
+
+// Cancel 请求未能得出结果（如被取消），释放占用的探测名额
+func (b *Breaker) Cancel(key Key) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c := b.circuit(key); c.State == HalfOpen && c.probes > 0 {
+		c.probes--
+	}
+}
+
+// State 获取熔断对象状态
+func (b *Breaker) State(key Key) State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c, ok := b.circuits[key]; ok {
+		return c.State
+	}
+	return Closed
+}
+
+// States 获取所有熔断对象状态，按类型及名称排序
+func (b *Breaker) States() []Status {
+	b.mu.Lock()
+	list := make([]Status, 0, len(b.circuits))
+	for _, c := range b.circuits {
+		list = append(list, c.Status)
+	}
+	b.mu.Unlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].Key.String() < list[j].Key.String() })
+	return list
+}
+
+// ServeHTTP 以 JSON 输出所有熔断对象状态，可挂载到管理端口供看板使用
+func (b *Breaker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	json.NewEncoder(w).Encode(b.States())
+}
@@ -0,0 +1,33 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	b := New(Settings{Threshold: 2, Cooldown: 20 * time.Millisecond})
+	dev := Key{Kind: KindDevice, Name: "bsj00575"}
+	for i := 0; i < 2; i++ {
+		if err := b.Allow(dev); err != nil {
+			t.Fatal(err)
+		}
+		b.Failure(dev, errors.New("device offline"))
+	}
+	var oe *OpenError
+	if err := b.Allow(dev); !errors.As(err, &oe) {
+		t.Fatalf("expected open circuit, got %v", err)
+	}
+	time.Sleep(25 * time.Millisecond)
+	if err := b.Allow(dev); err != nil {
+		t.Fatalf("expected half-open probe, got %v", err)
+	}
+	if err := b.Allow(dev); err == nil {
+		t.Fatal("only one probe should be allowed")
+	}
+	b.Success(dev)
+	if s := b.States(); len(s) != 1 || s[0].State != Closed || s[0].LastError != "device offline" {
+		t.Fatalf("unexpected states: %+v", s)
+	}
+}
@@ -1,15 +1,40 @@
 package yxyiot
 
 import (
+	"context"
+	"time"
+
+	"github.com/bigrocs/yxyiot/breaker"
 	"github.com/bigrocs/yxyiot/common"
 	"github.com/bigrocs/yxyiot/config"
+	"github.com/bigrocs/yxyiot/metrics"
+	"github.com/bigrocs/yxyiot/policy"
+	"github.com/bigrocs/yxyiot/ratelimit"
+	"github.com/bigrocs/yxyiot/registry"
 	"github.com/bigrocs/yxyiot/requests"
 	"github.com/bigrocs/yxyiot/responses"
+	"github.com/bigrocs/yxyiot/tracing"
+	"github.com/bigrocs/yxyiot/util"
 )
 
 // Client the type Client
 type Client struct {
-	Config *config.Config
+	Config       *config.Config
+	Logger       Logger             // 日志输出，为空时不记录
+	LogLevel     LogLevel           // 日志详细程度
+	LogRedactor  Redactor           // 日志脱敏函数，为空时使用 DefaultRedactor
+	Metrics      metrics.Metrics    // 监控指标，为空时不统计
+	Tracer       tracing.Tracer     // 链路追踪，为空时不记录
+	RateLimiter  *ratelimit.Limiter // 客户端限流，为空时不限流
+	Breaker      *breaker.Breaker   // 按接口地址及设备熔断，为空时不熔断
+	Policy       *policy.Engine     // 播报策略（静默时段等），为空时不限制
+	Registry     *registry.Registry // 设备注册表，用于解析设备别名、门店及分组
+	PrintStates  PrintStateStore    // 打印任务状态存储，为空时不记录
+	interceptors []Interceptor
+
+	// ClearStaleBefore 打印小票前查询打印机队列，最早的待打印任务积压超过该时长时清空队列，
+	// 避免设备恢复后集中打出过期小票；Print、调度器及备用路径发送的打印均会检查，每次额外查询一次队列，为 0 时不检查
+	ClearStaleBefore time.Duration
 }
 
 // NewClient 创建默认连接
@@ -20,23 +45,109 @@ func NewClient() (client *Client) {
 	return
 }
 
+// Use 追加请求拦截器，先追加的位于外层；应在发起请求前调用
+func (client *Client) Use(interceptors ...Interceptor) {
+	client.interceptors = append(client.interceptors, interceptors...)
+}
+
 // ProcessCommonRequest 处理公共请求
 func (client *Client) ProcessCommonRequest(request *requests.CommonRequest) (response *responses.CommonResponse, err error) {
+	return client.ProcessCommonRequestWithContext(context.Background(), request)
+}
+
+// ProcessCommonRequestWithContext 携带上下文处理公共请求
+func (client *Client) ProcessCommonRequestWithContext(ctx context.Context, request *requests.CommonRequest) (response *responses.CommonResponse, err error) {
 	response = responses.NewCommonResponse(client.Config, request)
-	err = client.DoAction(request, response)
+	err = client.DoActionWithContext(ctx, request, response)
 	return
 }
 
 // DoAction 执行动作
 func (client *Client) DoAction(request *requests.CommonRequest, response *responses.CommonResponse) (err error) {
+	return client.DoActionWithContext(context.Background(), request, response)
+}
+
+// DoActionWithContext 携带上下文执行动作，依次经过已注册的拦截器
+func (client *Client) DoActionWithContext(ctx context.Context, request *requests.CommonRequest, response *responses.CommonResponse) (err error) {
+	client.clearStaleJobs(ctx, request)
+	st := &callState{}
+	ctx = context.WithValue(ctx, callStateKey{}, st)
+	start := time.Now()
+	client.logStart(request)
+	client.metricsStart(request)
+	err = Chain(client.interceptors...)(client.doAction)(ctx, request, response)
+	if response != nil && response.Request != nil {
+		request = response.Request // 拦截器可能替换了请求
+	}
+	elapsed := time.Since(start)
+	client.logFinish(request, response, err, st, elapsed)
+	client.metricsFinish(request, response, err, st, elapsed)
+	return err
+}
+
+// doAction 发送请求
+func (client *Client) doAction(ctx context.Context, request *requests.CommonRequest, response *responses.CommonResponse) (err error) {
+	if request, err = client.applyPolicy(request, response); err != nil {
+		return err
+	}
+	if err = client.checkCapability(request); err != nil {
+		return err
+	}
 	// 创建访问链接
 	u := &common.Common{
 		Config:   client.Config,
 		Requests: request,
+		Tracer:   client.Tracer,
 	}
-	err = u.Action(response)
+	devName := util.InterfaceToString(request.BizContent["devName"])
+	sent := false
+	if client.Breaker != nil {
+		keys := []breaker.Key{{Kind: breaker.KindEndpoint, Name: u.Endpoint()}}
+		if devName != "" {
+			keys = append(keys, breaker.Key{Kind: breaker.KindDevice, Name: devName})
+		}
+		if err = client.Breaker.Allow(keys...); err != nil {
+			return err
+		}
+		defer func() { client.recordBreaker(ctx, keys, response, err, sent) }()
+	}
+	if client.RateLimiter != nil {
+		if err = client.RateLimiter.Take(ctx, client.Config.AppId, devName); err != nil {
+			return err
+		}
+	}
+	addAttempt(ctx)
+	sent = true
+	err = u.ActionWithContext(ctx, response)
 	if err != nil {
 		return err
 	}
 	return
 }
+
+// recordBreaker 记录熔断结果：传输错误计入接口地址，表示设备离线或不存在的业务错误计入设备
+func (client *Client) recordBreaker(ctx context.Context, keys []breaker.Key, response *responses.CommonResponse, err error, sent bool) {
+	for _, key := range keys {
+		switch {
+		case !sent || (err != nil && ctx.Err() != nil):
+			client.Breaker.Cancel(key)
+		case err != nil:
+			if key.Kind == breaker.KindEndpoint {
+				client.Breaker.Failure(key, err)
+			} else {
+				client.Breaker.Cancel(key)
+			}
+		case response.GetAPIError() != nil:
+			switch e := response.GetAPIError(); {
+			case key.Kind == breaker.KindEndpoint:
+				client.Breaker.Success(key)
+			case e.DeviceUnavailable():
+				client.Breaker.Failure(key, e)
+			default:
+				client.Breaker.Cancel(key) // 与设备无关的业务错误（签名、参数等）不计入设备熔断
+			}
+		default:
+			client.Breaker.Success(key)
+		}
+	}
+}
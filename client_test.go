@@ -1,11 +1,18 @@
 package yxyiot
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"testing"
 
+	"github.com/bigrocs/yxyiot/breaker"
+	"github.com/bigrocs/yxyiot/dispatcher"
+	"github.com/bigrocs/yxyiot/policy"
+	"github.com/bigrocs/yxyiot/registry"
 	"github.com/bigrocs/yxyiot/requests"
+	"github.com/bigrocs/yxyiot/responses"
 )
 
 func TestScan(t *testing.T) {
@@ -103,3 +110,62 @@ func TestPrint(t *testing.T) { // 打印机播报模式
 	// fmt.Println("TestPlay", r, err)
 	// t.Log(r, err, "|||")
 }
+
+func TestRecordBreaker(t *testing.T) {
+	defer func(codes []string) { responses.DeviceUnavailableCodes = codes }(responses.DeviceUnavailableCodes)
+	responses.DeviceUnavailableCodes = []string{"1001"}
+	client := NewClient()
+	client.Breaker = breaker.New(breaker.Settings{Threshold: 1})
+	device := breaker.Key{Kind: breaker.KindDevice, Name: "bsj00575"}
+	for _, reply := range []string{`{"code":4001,"msg":"invalid sign"}`, `{"code":1001,"msg":"device offline"}`} {
+		if err := client.Breaker.Allow(device); err != nil {
+			t.Fatalf("device breaker opened by %s: %v", reply, err)
+		}
+		response := responses.NewCommonResponse(nil, nil)
+		response.SetHttpContent([]byte(reply), "string")
+		client.recordBreaker(context.Background(), []breaker.Key{device}, response, nil, true)
+	}
+	if s := client.Breaker.State(device); s != breaker.Open {
+		t.Fatalf("device breaker state = %v, want open", s)
+	}
+}
+
+func TestCapabilityCheckedForReroutedAndDispatchedRequests(t *testing.T) {
+	reg, err := registry.New([]registry.Device{
+		{Name: "bsj00575", Type: registry.TypeSpeaker},
+		{Name: "bsj00576", Type: registry.TypeSpeaker}, // 误配置为改投目标的云喇叭
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient()
+	client.Registry = reg
+	client.Policy, err = policy.New(policy.Config{Rules: []policy.Rule{{
+		Devices: []string{"bsj00575"},
+		Quiet:   []policy.Window{{Start: "00:00", End: "12:00"}, {Start: "12:00", End: "00:00"}},
+		Action:  policy.ActionReroute,
+		Printer: "bsj00576",
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var capErr *registry.CapabilityError
+	_, err = client.Play(context.Background(), requests.NewPlayRequest("bsj00575", "张三收款成功3467.91元"))
+	if !errors.As(err, &capErr) || capErr.DevName != "bsj00576" || capErr.Capability != registry.CapPrint {
+		t.Fatalf("expected print capability error for rerouted request, got %v", err)
+	}
+
+	results := make(chan dispatcher.Result, 1)
+	d, err := dispatcher.New(client, dispatcher.Options{OnResult: func(r dispatcher.Result) { results <- r }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if _, err = d.Enqueue(dispatcher.NewPrintJob(requests.NewPrintRequest("bsj00576", "订单编号: 1200897812792015996<BR>"))); err != nil {
+		t.Fatal(err)
+	}
+	r := <-results
+	if r.Status != dispatcher.StatusFailed || !errors.As(r.Err, &capErr) || r.Job.Attempts != 1 {
+		t.Fatalf("expected capability failure without retry, got %+v", r)
+	}
+}
@@ -0,0 +1,63 @@
+package common
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrUnknownApi 请求的接口未注册
+var ErrUnknownApi = errors.New("yxyiot: unknown api")
+
+// defaultApis 内置接口，仅包含平台文档已确认的播报与打印接口
+// 其余接口需按平台文档通过 RegisterApi 注册，尚无文档的接口见 README「待平台文档确认」
+// 平台未确认 play 接口接受 form post，确认后可通过 RegisterApi 注册 PostAllowed 为 true 的 play 接口开启
+var defaultApis = []Api{
+	{
+		Name:   "play",
+		Method: "get",
+		URL:    "/v1/openApi/dev/controlDevice.json",
+	}, {
+		Name:   "print",
+		Method: "post",
+		URL:    "/v1/openApi/dev/customPrint.json",
+	},
+}
+
+var (
+	apisMu sync.RWMutex
+	apis   = make(map[string]Api)
+)
+
+func init() {
+	for _, api := range defaultApis {
+		apis[api.Name] = api
+	}
+}
+
+// RegisterApi 注册接口，同名接口将被覆盖；可用于对接平台新增接口或调整接口地址
+func RegisterApi(api Api) {
+	apisMu.Lock()
+	defer apisMu.Unlock()
+	apis[api.Name] = api
+}
+
+// LookupApi 按名称查找接口
+func LookupApi(name string) (Api, bool) {
+	apisMu.RLock()
+	defer apisMu.RUnlock()
+	api, ok := apis[name]
+	return api, ok
+}
+
+// Apis 列出已注册的接口，按名称排序
+func Apis() []Api {
+	apisMu.RLock()
+	defer apisMu.RUnlock()
+	list := make([]Api, 0, len(apis))
+	for _, api := range apis {
+		list = append(list, api)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
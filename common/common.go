@@ -1,12 +1,16 @@
 package common
 
 import (
+	"context"
+	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
 	"github.com/bigrocs/yxyiot/config"
 	"github.com/bigrocs/yxyiot/requests"
 	"github.com/bigrocs/yxyiot/responses"
+	"github.com/bigrocs/yxyiot/tracing"
 	"github.com/bigrocs/yxyiot/util"
 	uuid "github.com/satori/go.uuid"
 )
@@ -15,28 +19,28 @@ import (
 type Common struct {
 	Config   *config.Config
 	Requests *requests.CommonRequest
+	Tracer   tracing.Tracer // 链路追踪，为空时不记录
 }
+
+// Api 平台接口
 type Api struct {
-	Name   string
-	Method string
-	URL    string
+	Name        string
+	Method      string
+	URL         string
+	PostAllowed bool // get 接口是否允许以 form post 方式请求
 }
 
-var apiList = []Api{
-	{
-		Name:   "play",
-		Method: "get",
-		URL:    "/v1/openApi/dev/controlDevice.json",
-	}, {
-		Name:   "print",
-		Method: "post",
-		URL:    "/v1/openApi/dev/customPrint.json",
-	},
-}
+// DefaultMaxURLLength get 请求链接默认最大长度
+const DefaultMaxURLLength = 2048
 
 // Action 创建新的公共连接
 func (c *Common) Action(response *responses.CommonResponse) (err error) {
-	return c.Request(response)
+	return c.ActionWithContext(context.Background(), response)
+}
+
+// ActionWithContext 携带上下文创建新的公共连接
+func (c *Common) ActionWithContext(ctx context.Context, response *responses.CommonResponse) (err error) {
+	return c.RequestWithContext(ctx, response)
 }
 
 // APIBaseURL 默认 API 网关
@@ -48,6 +52,27 @@ func (c *Common) APIBaseURL() string { // TODO(): 后期做容灾功能
 	return "https://ioe.car900.com"
 }
 
+// api 查找当前请求对应的接口
+func (c *Common) api() (Api, bool) {
+	return LookupApi(c.Requests.ApiName)
+}
+
+// Endpoint 当前请求的接口地址
+func (c *Common) Endpoint() string {
+	if api, ok := c.api(); ok {
+		return c.APIBaseURL() + api.URL
+	}
+	return ""
+}
+
+// maxURLLength get 请求链接最大长度
+func (c *Common) maxURLLength() int {
+	if c.Config.MaxURLLength > 0 {
+		return c.Config.MaxURLLength
+	}
+	return DefaultMaxURLLength
+}
+
 // Request 执行请求
 // AppCode           string `json:"app_code"`             //API编码
 // AppId             string `json:"app_id"`               //应用ID
@@ -61,17 +86,26 @@ func (c *Common) APIBaseURL() string { // TODO(): 后期做容灾功能
 // BizContent        string `json:"biz_content"`          //业务请求参数的集合，最大长度不限，除公共参数外所有请求参数都必须放在这个参数中传递，具体参照各产品快速接入文档
 // Sandbox           bool   `json:"sandbox"`              // 沙盒
 func (c *Common) Request(response *responses.CommonResponse) (err error) {
+	return c.RequestWithContext(context.Background(), response)
+}
+
+// RequestWithContext 携带上下文执行请求
+func (c *Common) RequestWithContext(ctx context.Context, response *responses.CommonResponse) (err error) {
 	con := c.Config
 	req := c.Requests
-	apiUrl := ""
-	method := ""
-	for _, api := range apiList {
-		if api.Name == req.ApiName {
-			apiUrl = c.APIBaseURL() + api.URL
-			method = api.Method
-		}
+	api, ok := c.api()
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownApi, req.ApiName)
 	}
+	apiUrl := c.APIBaseURL() + api.URL
+	method := api.Method
+	postAllowed := api.PostAllowed
+	tracer := c.tracer()
+	ctx, span := tracer.Start(ctx, "yxyiot."+req.ApiName)
+	defer func() { span.End(err) }()
+	span.SetAttributes("api", req.ApiName, "endpoint", apiUrl, "devName", util.InterfaceToString(req.BizContent["devName"]))
 	// 构建配置参数
+	_, signSpan := tracer.Start(ctx, "yxyiot.sign")
 	params := map[string]interface{}{
 		"timestamp": time.Now().UnixNano() / 1e6,
 		"appId":     con.AppId,
@@ -88,16 +122,43 @@ func (c *Common) Request(response *responses.CommonResponse) (err error) {
 		params[k] = v
 	}
 	urlParam := util.FormatURLParam(params)
+	signSpan.End(nil)
+	span.SetAttributes("requestId", util.InterfaceToString(params["requestId"]))
+	if method == "get" && postAllowed && (con.GetAsPost || len(apiUrl)+1+len(urlParam) > c.maxURLLength()) {
+		method = "post" // 避免 token 及播报内容出现在链接中
+	}
+	httpCtx, httpSpan := tracer.Start(ctx, "yxyiot.http")
+	httpSpan.SetAttributes("method", method)
+	header := http.Header{}
+	tracer.Inject(httpCtx, header)
 	var res []byte
 	switch method {
 	case "get":
-		res, err = util.HTTPGet(apiUrl + "?" + urlParam)
+		res, err = util.HTTPGetWithContext(httpCtx, apiUrl+"?"+urlParam, header)
 	case "post":
-		res, err = util.PostForm(apiUrl, urlParam)
+		res, err = util.PostFormWithContext(httpCtx, apiUrl, urlParam, header)
 	}
 	if err != nil {
+		err = util.RedactError(err, token, con.AppSecret)
+		httpSpan.End(err)
 		return err
 	}
+	httpSpan.End(nil)
+	_, decodeSpan := tracer.Start(ctx, "yxyiot.decode")
 	response.SetHttpContent(res, "string")
+	if e := response.GetAPIError(); e != nil {
+		decodeSpan.SetAttributes("code", e.Code)
+		decodeSpan.End(e)
+		return
+	}
+	decodeSpan.End(nil)
 	return
 }
+
+// tracer 链路追踪实现，未设置时不记录
+func (c *Common) tracer() tracing.Tracer {
+	if c.Tracer != nil {
+		return c.Tracer
+	}
+	return tracing.Nop
+}
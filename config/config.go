@@ -1,7 +1,9 @@
 package config
 
 type Config struct {
-	AppId     string `json:"appId"`     // 开发者ID
-	AppSecret string `json:"appSecret"` // 开发者密钥
-	Sandbox   bool   `json:"sandbox"`   // 沙盒
+	AppId        string `json:"appId"`        // 开发者ID
+	AppSecret    string `json:"appSecret"`    // 开发者密钥
+	Sandbox      bool   `json:"sandbox"`      // 沙盒
+	GetAsPost    bool   `json:"getAsPost"`    // 接口允许时（见 common.Api.PostAllowed）get 请求改用 form post 发送
+	MaxURLLength int    `json:"maxUrlLength"` // get 请求链接最大长度，接口允许时超出后自动改用 post（0 使用默认值）
 }
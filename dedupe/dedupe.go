@@ -0,0 +1,73 @@
+package dedupe
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// ErrDuplicate 业务去重键在有效期内已处理，请求被忽略
+var ErrDuplicate = errors.New("yxyiot: duplicate request suppressed")
+
+// namespace 生成 requestId 的命名空间
+var namespace = uuid.NewV5(uuid.NamespaceURL, "github.com/bigrocs/yxyiot/dedupe")
+
+// RequestId 由接口名称及业务去重键（如订单号）生成稳定的 requestId
+func RequestId(apiName, bizKey string) string {
+	return uuid.NewV5(namespace, apiName+":"+bizKey).String()
+}
+
+// Store 带有效期的去重存储
+type Store interface {
+	// SetIfAbsent 记录 key，key 已存在且未过期时返回 false
+	SetIfAbsent(key string, ttl time.Duration) (bool, error)
+	// Delete 删除 key，用于请求失败后允许重新发送
+	Delete(key string) error
+	// Close 关闭存储
+	Close() error
+}
+
+// MemoryStore 内存去重存储
+type MemoryStore struct {
+	mu      sync.Mutex
+	expires map[string]time.Time
+	sweep   time.Time
+}
+
+// NewMemoryStore 创建内存去重存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{expires: make(map[string]time.Time)}
+}
+
+// SetIfAbsent 记录 key，key 已存在且未过期时返回 false
+func (s *MemoryStore) SetIfAbsent(key string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.After(s.sweep) { // 定期清理过期记录
+		for k, exp := range s.expires {
+			if !exp.After(now) {
+				delete(s.expires, k)
+			}
+		}
+		s.sweep = now.Add(time.Minute)
+	}
+	if exp, ok := s.expires[key]; ok && exp.After(now) {
+		return false, nil
+	}
+	s.expires[key] = now.Add(ttl)
+	return true, nil
+}
+
+// Delete 删除 key
+func (s *MemoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.expires, key)
+	return nil
+}
+
+// Close 关闭存储
+func (s *MemoryStore) Close() error { return nil }
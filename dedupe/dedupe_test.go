@@ -0,0 +1,35 @@
+package dedupe
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dedupe.log")
+	s, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := s.SetIfAbsent("play:1200897812792015996", time.Hour); !ok {
+		t.Fatal("first request should pass")
+	}
+	s.SetIfAbsent("play:expired", time.Nanosecond)
+	s.Close()
+
+	s, err = OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if ok, _ := s.SetIfAbsent("play:1200897812792015996", time.Hour); ok {
+		t.Fatal("duplicate should be suppressed after restart")
+	}
+	if ok, _ := s.SetIfAbsent("play:expired", time.Hour); !ok {
+		t.Fatal("expired key should be accepted")
+	}
+	if RequestId("play", "A1") != RequestId("play", "A1") || RequestId("play", "A1") == RequestId("print", "A1") {
+		t.Fatal("requestId should be stable per api and key")
+	}
+}
@@ -0,0 +1,105 @@
+package dedupe
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/bigrocs/yxyiot/internal/wal"
+)
+
+// record 日志记录，Expire 为 0 表示删除
+type record struct {
+	Key    string `json:"key"`
+	Expire int64  `json:"exp"` // 过期时间（毫秒时间戳）
+}
+
+// FileStore 基于追加日志的文件去重存储，进程重启后仍能识别重复请求
+type FileStore struct {
+	mu      sync.Mutex
+	log     *wal.Log
+	expires map[string]int64
+}
+
+// OpenFileStore 打开或创建文件去重存储
+func OpenFileStore(path string) (*FileStore, error) {
+	s := &FileStore{expires: make(map[string]int64)}
+	log, err := wal.Open(path, s.apply, s.snapshot)
+	if err != nil {
+		return nil, err
+	}
+	s.log = log
+	return s, nil
+}
+
+// apply 重放一条日志记录，忽略不完整的记录
+func (s *FileStore) apply(line []byte) {
+	var r record
+	if json.Unmarshal(line, &r) != nil {
+		return
+	}
+	if r.Expire == 0 {
+		delete(s.expires, r.Key)
+	} else {
+		s.expires[r.Key] = r.Expire
+	}
+}
+
+// snapshot 丢弃过期记录并写出其余记录
+func (s *FileStore) snapshot(write func(v interface{}) error) error {
+	now := time.Now().UnixNano() / 1e6
+	for k, exp := range s.expires {
+		if exp <= now {
+			delete(s.expires, k)
+			continue
+		}
+		if err := write(record{Key: k, Expire: exp}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// append 追加记录并落盘，调用方需持有锁
+func (s *FileStore) append(r record) error {
+	if s.log.Closed() {
+		return errors.New("dedupe: store closed")
+	}
+	return s.log.Append(r, len(s.expires))
+}
+
+// SetIfAbsent 记录 key，key 已存在且未过期时返回 false
+func (s *FileStore) SetIfAbsent(key string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if exp, ok := s.expires[key]; ok && exp > now.UnixNano()/1e6 {
+		return false, nil
+	}
+	exp := now.Add(ttl).UnixNano() / 1e6
+	s.expires[key] = exp
+	if err := s.append(record{Key: key, Expire: exp}); err != nil {
+		delete(s.expires, key)
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete 删除 key
+func (s *FileStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.expires[key]; !ok {
+		return nil
+	}
+	delete(s.expires, key)
+	return s.append(record{Key: key})
+}
+
+// Close 关闭存储
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.log.Close()
+}
@@ -0,0 +1,60 @@
+package yxyiot
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bigrocs/yxyiot/registry"
+	"github.com/bigrocs/yxyiot/requests"
+)
+
+// device 按设备名称或别名查找注册表中的设备，未配置设备注册表或未找到时返回 false
+func (client *Client) device(name string) (registry.Device, bool) {
+	if client.Registry == nil {
+		return registry.Device{}, false
+	}
+	return client.Registry.Device(name)
+}
+
+// checkCapability 校验请求是否在注册表所记录的设备能力范围内，未登记的设备不校验
+// 在 doAction 中执行，策略改投、门店备用路径及调度器发送的请求同样经过校验
+func (client *Client) checkCapability(request *requests.CommonRequest) error {
+	devName, _ := request.BizContent["devName"].(string)
+	d, ok := client.device(devName)
+	if !ok {
+		return nil
+	}
+	return registry.Validate(d, request)
+}
+
+// PlayTarget 向目标下的所有设备播报，目标格式见 registry.Registry.Resolve
+func (client *Client) PlayTarget(ctx context.Context, target string, request requests.PlayRequest, opts *BatchOptions) (*BatchResult, error) {
+	if client.Registry == nil {
+		return nil, fmt.Errorf("yxyiot: device registry not configured")
+	}
+	devices, err := client.Registry.Resolve(target)
+	if err != nil {
+		return nil, err
+	}
+	return client.PlayBatch(ctx, devices, request, opts)
+}
+
+// StoreProfile 由设备注册表生成门店配置，取门店下首个可播报设备与首个打印机
+func (client *Client) StoreProfile(storeId string) (*StoreProfile, error) {
+	if client.Registry == nil {
+		return nil, fmt.Errorf("yxyiot: device registry not configured")
+	}
+	profile := &StoreProfile{StoreId: storeId}
+	for _, d := range client.Registry.Store(storeId) {
+		if profile.Speaker == "" && d.Type == registry.TypeSpeaker {
+			profile.Speaker = d.Name
+		}
+		if profile.Printer == "" && d.Is(registry.TypePrinter) {
+			profile.Printer = d.Name
+		}
+	}
+	if profile.Speaker == "" && profile.Printer == "" {
+		return nil, &registry.NotFoundError{Target: "store:" + storeId}
+	}
+	return profile, nil
+}
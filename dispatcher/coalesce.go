@@ -0,0 +1,108 @@
+package dispatcher
+
+import (
+	"bytes"
+	"strconv"
+	"text/template"
+	"time"
+
+	"github.com/bigrocs/yxyiot/requests"
+	uuid "github.com/satori/go.uuid"
+)
+
+// DefaultCoalesceTemplate 默认合并播报模板
+const DefaultCoalesceTemplate = "收到{{.Count}}笔，共计{{.Total}}元"
+
+// CoalesceOptions 合并播报配置：同一设备在窗口期内的多笔收款播报合并为一条汇总
+type CoalesceOptions struct {
+	Window    time.Duration // 合并窗口，首笔播报最多延迟该时长
+	Template  string        // 汇总模板，可使用 {{.Count}}、{{.Total}}，默认 DefaultCoalesceTemplate
+	Threshold float64       // 金额不低于该值时单独播报，小于等于 0 时不限制
+}
+
+// Summary 汇总模板数据
+type Summary struct {
+	Count int    // 笔数
+	Total string // 合计金额，保留两位小数
+}
+
+// coalescer 合并播报状态
+type coalescer struct {
+	opts      CoalesceOptions
+	tmpl      *template.Template
+	deadlines map[string]time.Time // 各设备当前窗口的截止时间
+}
+
+// newCoalescer 创建合并播报状态，未配置窗口时返回 nil
+func newCoalescer(opts *CoalesceOptions) (*coalescer, error) {
+	if opts == nil || opts.Window <= 0 {
+		return nil, nil
+	}
+	text := opts.Template
+	if text == "" {
+		text = DefaultCoalesceTemplate
+	}
+	tmpl, err := template.New("coalesce").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &coalescer{opts: *opts, tmpl: tmpl, deadlines: make(map[string]time.Time)}, nil
+}
+
+// amount 解析播报金额
+func amount(job *Job) (float64, bool) {
+	if job.Kind != KindPlay || job.Play == nil || job.Play.Money == "" {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(job.Play.Money, 64)
+	return v, err == nil
+}
+
+// hold 判断任务是否参与合并，参与时将发送时间推迟到设备窗口截止时间，调用方需持有锁
+func (c *coalescer) hold(job *Job, now time.Time) {
+	if job.NoCoalesce || job.Cron != "" || job.NextAt.After(now) {
+		return
+	}
+	v, ok := amount(job)
+	if !ok || (c.opts.Threshold > 0 && v >= c.opts.Threshold) {
+		return
+	}
+	devName := job.DevName()
+	deadline, ok := c.deadlines[devName]
+	if !ok || !deadline.After(now) {
+		deadline = now.Add(c.opts.Window)
+		c.deadlines[devName] = deadline
+	}
+	job.Coalesce = true
+	job.NextAt = deadline
+}
+
+// merge 将多笔播报合并为一条汇总播报
+func (c *coalescer) merge(jobs []*Job) (*Job, error) {
+	total := 0.0
+	ids := make([]string, 0, len(jobs))
+	for _, job := range jobs {
+		v, _ := amount(job)
+		total += v
+		ids = append(ids, job.ID)
+	}
+	var buf bytes.Buffer
+	err := c.tmpl.Execute(&buf, Summary{Count: len(jobs), Total: strconv.FormatFloat(total, 'f', 2, 64)})
+	if err != nil {
+		return nil, err
+	}
+	first := jobs[0].Play
+	now := time.Now()
+	return &Job{
+		ID:   uuid.NewV5(uuid.NamespaceOID, jobs[0].ID+"/coalesce").String(),
+		Kind: KindPlay,
+		Play: &requests.PlayRequest{
+			DevName: first.DevName,
+			BizType: first.BizType,
+			Content: buf.String(),
+		},
+		NextAt:    now,
+		CreatedAt: now,
+		Merged:    ids,
+	}, nil
+}
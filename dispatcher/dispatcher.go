@@ -0,0 +1,485 @@
+package dispatcher
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bigrocs/yxyiot/dedupe"
+	"github.com/bigrocs/yxyiot/policy"
+	"github.com/bigrocs/yxyiot/registry"
+	"github.com/bigrocs/yxyiot/requests"
+	"github.com/bigrocs/yxyiot/responses"
+)
+
+// ErrClosed 调度器或存储已关闭
+var ErrClosed = errors.New("dispatcher: closed")
+
+// StoreError 任务存储写入失败，任务仍在内存中处理，但进程重启后可能丢失或重复发送
+type StoreError struct {
+	Op    string // put 或 delete
+	JobID string
+	Err   error
+}
+
+func (e *StoreError) Error() string {
+	return fmt.Sprintf("dispatcher: store %s job %s: %v", e.Op, e.JobID, e.Err)
+}
+
+func (e *StoreError) Unwrap() error { return e.Err }
+
+// Sender 请求发送方，*yxyiot.Client 即满足该接口
+type Sender interface {
+	ProcessCommonRequestWithContext(ctx context.Context, request *requests.CommonRequest) (*responses.CommonResponse, error)
+}
+
+// 任务最终状态
+const (
+	StatusDelivered = "delivered" // 发送成功
+	StatusFailed    = "failed"    // 重试耗尽或不可重试
+	StatusCoalesced = "coalesced" // 已合并到汇总播报，最终结果见 MergedInto 对应任务
+	StatusDuplicate = "duplicate" // 业务去重键已处理，未重复发送
+	StatusCanceled  = "canceled"  // 发送前被取消
+	StatusDropped   = "dropped"   // 被播报策略丢弃
+)
+
+// Result 任务最终结果
+type Result struct {
+	Job        *Job
+	Status     string
+	Response   *responses.CommonResponse
+	Err        error
+	MergedInto string // 合并后的汇总任务ID
+}
+
+// Options 调度器配置
+type Options struct {
+	Store        Store                                                    // 持久化存储，默认 MemoryStore
+	Workers      int                                                      // 全局并发发送数，默认 4；同一设备始终串行发送
+	Gap          func(job *Job) time.Duration                             // 同一设备两次发送之间的间隔，默认 SpeechGap(DefaultGapBase, DefaultGapPerChar)
+	MaxAttempts  int                                                      // 最多发送次数，默认 5
+	Backoff      time.Duration                                            // 首次重试等待时间，之后逐次翻倍，默认 1 秒
+	MaxBackoff   time.Duration                                            // 最长重试等待时间，默认 5 分钟
+	Timeout      time.Duration                                            // 单次发送超时，默认 10 秒
+	Retryable    func(response *responses.CommonResponse, err error) bool // 判断失败是否可重试，默认仅重试传输错误
+	OnResult     func(Result)                                             // 任务结束回调
+	OnStoreError func(err *StoreError)                                    // 发送过程中存储写入失败回调，Enqueue 等提交时的失败直接返回给调用方
+	Coalesce     *CoalesceOptions                                         // 合并播报配置，为空时逐条播报
+}
+
+// Dispatcher 异步发送播报及打印任务，任务先持久化再发送，失败时按退避策略重试
+// 同一设备的任务按顺序逐个发送并在播报后留出间隔，不同设备之间互不影响
+type Dispatcher struct {
+	sender Sender
+	opts   Options
+
+	mu     sync.Mutex
+	queue  jobHeap
+	lanes  map[string]*lane
+	active map[string]bool // 未结束的任务ID
+	merger *coalescer
+	closed bool
+	wake   chan struct{}
+	sem    chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New 创建调度器，并恢复存储中未完成的任务
+func New(sender Sender, opts Options) (*Dispatcher, error) {
+	if opts.Store == nil {
+		opts.Store = NewMemoryStore()
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+	if opts.Gap == nil {
+		opts.Gap = SpeechGap(DefaultGapBase, DefaultGapPerChar)
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 5 * time.Minute
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.Retryable == nil {
+		opts.Retryable = TransportErrorRetryable
+	}
+	merger, err := newCoalescer(opts.Coalesce)
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := opts.Store.List()
+	if err != nil {
+		return nil, err
+	}
+	d := &Dispatcher{
+		merger: merger,
+		sender: sender,
+		opts:   opts,
+		lanes:  make(map[string]*lane),
+		active: make(map[string]bool),
+		wake:   make(chan struct{}, 1),
+		sem:    make(chan struct{}, opts.Workers),
+	}
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	merged := make(map[string]bool)
+	for _, job := range jobs {
+		for _, id := range job.Merged {
+			merged[id] = true
+		}
+	}
+	for _, job := range jobs {
+		if merged[job.ID] { // 已合并但未及删除的任务
+			d.delete(job.ID)
+			continue
+		}
+		heap.Push(&d.queue, job)
+		d.active[job.ID] = true
+	}
+	d.wg.Add(1)
+	go d.loop()
+	return d, nil
+}
+
+// TransportErrorRetryable 默认重试策略：传输错误可重试，平台业务错误及超出设备能力的请求不重试
+func TransportErrorRetryable(response *responses.CommonResponse, err error) bool {
+	var (
+		apiErr *responses.APIError
+		capErr *registry.CapabilityError
+	)
+	return err != nil && !errors.As(err, &apiErr) && !errors.As(err, &capErr)
+}
+
+// Enqueue 持久化并提交任务，返回任务ID；持久化失败时返回 *StoreError，任务不会发送
+// 相同业务去重键的任务尚未结束时直接返回已有任务ID，该去重仅覆盖未结束的任务；
+// 任务结束后的去重由客户端的 yxyiot.Dedupe 拦截器负责，命中时任务以 StatusDuplicate 结束
+func (d *Dispatcher) Enqueue(job *Job) (id string, err error) {
+	return d.submit(job, time.Time{})
+}
+
+// submit 持久化并提交任务，at 不为零值时在该时间发送
+func (d *Dispatcher) submit(job *Job, at time.Time) (id string, err error) {
+	now := time.Now()
+	job = job.clone()
+	if !at.IsZero() {
+		job.NextAt = at
+	}
+	job.init(now)
+	if _, err = job.CommonRequest(); err != nil {
+		return "", err
+	}
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return "", ErrClosed
+	}
+	if d.active[job.ID] { // 相同业务去重键的任务尚未结束
+		d.mu.Unlock()
+		return job.ID, nil
+	}
+	d.active[job.ID] = true
+	d.mu.Unlock()
+	if err = d.opts.Store.Put(job); err != nil {
+		d.mu.Lock()
+		delete(d.active, job.ID)
+		d.mu.Unlock()
+		return "", &StoreError{Op: "put", JobID: job.ID, Err: err}
+	}
+	if d.merger != nil {
+		d.mu.Lock()
+		d.merger.hold(job, now)
+		d.mu.Unlock()
+	}
+	d.push(job)
+	return job.ID, nil
+}
+
+// Close 停止调度并等待发送中的任务结束，未完成的任务保留在存储中，随后关闭存储
+func (d *Dispatcher) Close() error {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return nil
+	}
+	d.closed = true
+	d.mu.Unlock()
+	d.cancel()
+	d.wg.Wait()
+	return d.opts.Store.Close()
+}
+
+// push 将任务放入等待队列并唤醒调度
+func (d *Dispatcher) push(job *Job) {
+	d.mu.Lock()
+	if !d.closed {
+		heap.Push(&d.queue, job)
+	}
+	d.mu.Unlock()
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// loop 按发送时间取出到期任务交给对应设备的发送队列
+func (d *Dispatcher) loop() {
+	defer d.wg.Done()
+	for {
+		now := time.Now()
+		wait := time.Hour
+		d.mu.Lock()
+		var crons []*Job
+		for d.queue.Len() > 0 && !d.queue[0].NextAt.After(now) {
+			job := heap.Pop(&d.queue).(*Job)
+			if job.Cron != "" {
+				crons = append(crons, job)
+				continue
+			}
+			d.dispatch(job)
+		}
+		if d.queue.Len() > 0 {
+			wait = d.queue[0].NextAt.Sub(now)
+		}
+		d.mu.Unlock()
+		for _, job := range crons {
+			d.fire(job)
+		}
+		if len(crons) > 0 {
+			continue
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-d.ctx.Done():
+			timer.Stop()
+			return
+		case <-d.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// lane 单台设备的发送队列
+type lane struct {
+	devName string
+	jobs    []*Job
+}
+
+// dispatch 将任务加入设备发送队列，队列不存在时启动发送协程，调用方需持有锁
+func (d *Dispatcher) dispatch(job *Job) {
+	devName := job.DevName()
+	l, ok := d.lanes[devName]
+	if !ok {
+		l = &lane{devName: devName}
+		d.lanes[devName] = l
+		d.wg.Add(1)
+		go d.run(l)
+	}
+	l.jobs = append(l.jobs, job)
+}
+
+// run 依次发送设备队列中的任务，队列为空时退出
+func (d *Dispatcher) run(l *lane) {
+	defer d.wg.Done()
+	for {
+		d.mu.Lock()
+		if len(l.jobs) == 0 || d.ctx.Err() != nil {
+			delete(d.lanes, l.devName)
+			d.mu.Unlock()
+			return
+		}
+		job := l.jobs[0]
+		l.jobs = l.jobs[1:]
+		var batch []*Job
+		if job.Coalesce {
+			batch = append(batch, job)
+			rest := l.jobs[:0]
+			for _, j := range l.jobs {
+				if j.Coalesce {
+					batch = append(batch, j)
+				} else {
+					rest = append(rest, j)
+				}
+			}
+			l.jobs = rest
+		}
+		d.mu.Unlock()
+		if len(batch) > 1 {
+			job = d.coalesce(batch)
+		}
+
+		select {
+		case d.sem <- struct{}{}:
+		case <-d.ctx.Done():
+			continue
+		}
+		delivered := d.deliver(job)
+		<-d.sem
+		if !delivered {
+			continue
+		}
+		if gap := d.opts.Gap(job); gap > 0 {
+			timer := time.NewTimer(gap)
+			select {
+			case <-timer.C:
+			case <-d.ctx.Done():
+				timer.Stop()
+			}
+		}
+	}
+}
+
+// coalesce 将多笔播报合并为汇总任务，合并失败时逐条发送
+func (d *Dispatcher) coalesce(batch []*Job) *Job {
+	summary, err := d.merger.merge(batch)
+	if err == nil {
+		err = d.put(summary)
+	}
+	if err != nil {
+		for _, job := range batch[1:] {
+			job.Coalesce = false
+			d.push(job)
+		}
+		return batch[0]
+	}
+	d.mu.Lock()
+	d.active[summary.ID] = true
+	for _, job := range batch {
+		delete(d.active, job.ID)
+	}
+	d.mu.Unlock()
+	for _, job := range batch {
+		d.delete(job.ID)
+		if d.opts.OnResult != nil {
+			d.opts.OnResult(Result{Job: job, Status: StatusCoalesced, MergedInto: summary.ID})
+		}
+	}
+	return summary
+}
+
+// deliver 发送一次任务并处理结果，返回是否发送成功
+func (d *Dispatcher) deliver(job *Job) bool {
+	request, err := job.CommonRequest()
+	if err != nil {
+		d.finish(job, StatusFailed, nil, err)
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), d.opts.Timeout)
+	job.Attempts++
+	response, err := d.sender.ProcessCommonRequestWithContext(ctx, request)
+	cancel()
+	if err == nil {
+		if e := response.GetAPIError(); e != nil {
+			err = e
+		}
+	}
+	if err == nil {
+		d.finish(job, StatusDelivered, response, nil)
+		return true
+	}
+	if errors.Is(err, dedupe.ErrDuplicate) {
+		d.finish(job, StatusDuplicate, response, err)
+		return false
+	}
+	var dropped *policy.DroppedError
+	if errors.As(err, &dropped) {
+		d.finish(job, StatusDropped, response, err)
+		return false
+	}
+	var deferred *policy.DeferredError
+	if errors.As(err, &deferred) { // 静默时段结束后重新发送，不计入发送次数
+		job.Attempts--
+		job.LastError = err.Error()
+		job.NextAt = deferred.Until
+		d.put(job)
+		d.push(job)
+		return false
+	}
+	job.LastError = err.Error()
+	if job.Attempts < d.opts.MaxAttempts && d.opts.Retryable(response, err) {
+		job.NextAt = time.Now().Add(d.backoff(job.Attempts))
+		d.put(job) // 写入失败时任务仍在内存中重试，并通过 OnStoreError 报告
+		d.push(job)
+		return false
+	}
+	d.finish(job, StatusFailed, response, err)
+	return false
+}
+
+// backoff 第 attempts 次失败后的等待时间
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.opts.Backoff
+	for i := 1; i < attempts && wait < d.opts.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > d.opts.MaxBackoff {
+		wait = d.opts.MaxBackoff
+	}
+	return wait
+}
+
+// finish 结束任务并回调
+func (d *Dispatcher) finish(job *Job, status string, response *responses.CommonResponse, err error) {
+	d.delete(job.ID)
+	d.mu.Lock()
+	delete(d.active, job.ID)
+	d.mu.Unlock()
+	if d.opts.OnResult != nil {
+		d.opts.OnResult(Result{Job: job, Status: status, Response: response, Err: err})
+	}
+}
+
+// put 持久化任务，失败时通过 OnStoreError 报告
+func (d *Dispatcher) put(job *Job) error {
+	if err := d.opts.Store.Put(job); err != nil {
+		e := &StoreError{Op: "put", JobID: job.ID, Err: err}
+		d.storeError(e)
+		return e
+	}
+	return nil
+}
+
+// delete 删除已结束的任务，失败时通过 OnStoreError 报告
+func (d *Dispatcher) delete(id string) {
+	if err := d.opts.Store.Delete(id); err != nil {
+		d.storeError(&StoreError{Op: "delete", JobID: id, Err: err})
+	}
+}
+
+// storeError 回调存储写入失败
+func (d *Dispatcher) storeError(err *StoreError) {
+	if d.opts.OnStoreError != nil {
+		d.opts.OnStoreError(err)
+	}
+}
+
+// jobHeap 按发送时间排序的任务堆
+type jobHeap []*Job
+
+func (h jobHeap) Len() int { return len(h) }
+
+func (h jobHeap) Less(i, j int) bool { return h[i].NextAt.Before(h[j].NextAt) }
+
+func (h jobHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *jobHeap) Push(x interface{}) { *h = append(*h, x.(*Job)) }
+
+func (h *jobHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	job := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return job
+}
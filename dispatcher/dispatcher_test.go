@@ -0,0 +1,275 @@
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bigrocs/yxyiot/requests"
+	"github.com/bigrocs/yxyiot/responses"
+)
+
+// fakeSender 记录请求，前 fail 次返回传输错误
+type fakeSender struct {
+	mu         sync.Mutex
+	fail       int
+	requestIds []interface{}
+}
+
+func (s *fakeSender) ProcessCommonRequestWithContext(ctx context.Context, request *requests.CommonRequest) (*responses.CommonResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requestIds = append(s.requestIds, request.BizContent["requestId"])
+	response := responses.NewCommonResponse(nil, request)
+	if len(s.requestIds) <= s.fail {
+		return response, errors.New("connection refused")
+	}
+	response.SetHttpContent([]byte(`{"code":0}`), "string")
+	return response, nil
+}
+
+func TestDispatcherSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.wal")
+	store, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 首个调度器始终失败，关闭后任务应保留在日志中
+	d, err := New(&fakeSender{fail: 100}, Options{Store: store, Backoff: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := d.Enqueue(NewPrintJob(requests.NewPrintRequest("bsj00576", "订单编号: 1200897812792015996")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err = OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := &fakeSender{}
+	results := make(chan Result, 1)
+	d, err = New(sender, Options{Store: store, OnResult: func(r Result) { results <- r }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	select {
+	case r := <-results:
+		if r.Status != StatusDelivered || r.Job.ID != id || r.Job.Attempts != 2 {
+			t.Fatalf("unexpected result: %+v", r)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("job was not redelivered")
+	}
+	if sender.requestIds[0] != id {
+		t.Fatalf("requestId not stable: %v", sender.requestIds)
+	}
+	if jobs, _ := store.List(); len(jobs) != 0 {
+		t.Fatalf("delivered job still stored: %v", jobs)
+	}
+}
+
+// timedSender 记录每台设备的发送时间
+type timedSender struct {
+	mu    sync.Mutex
+	times map[string][]time.Time
+}
+
+func (s *timedSender) ProcessCommonRequestWithContext(ctx context.Context, request *requests.CommonRequest) (*responses.CommonResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	devName := request.BizContent["devName"].(string)
+	s.times[devName] = append(s.times[devName], time.Now())
+	response := responses.NewCommonResponse(nil, request)
+	response.SetHttpContent([]byte(`{"code":0}`), "string")
+	return response, nil
+}
+
+func TestDispatcherSerialPerDevice(t *testing.T) {
+	sender := &timedSender{times: make(map[string][]time.Time)}
+	var wg sync.WaitGroup
+	d, err := New(sender, Options{
+		Gap:      SpeechGap(0, 10*time.Millisecond),
+		OnResult: func(Result) { wg.Done() },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	wg.Add(3)
+	start := time.Now()
+	d.Enqueue(NewPlayJob(requests.NewPlayRequest("bsj00575", "张三收款成功3467.91元")))
+	d.Enqueue(NewPlayJob(requests.NewPlayRequest("bsj00575", "李四收款成功12.00元")))
+	d.Enqueue(NewPlayJob(requests.NewPlayRequest("bsj00576", "王五收款成功8.50元")))
+	wg.Wait()
+
+	times := sender.times["bsj00575"]
+	if len(times) != 2 || times[1].Sub(times[0]) < 140*time.Millisecond {
+		t.Fatalf("announcements on the same device not spaced: %v", times)
+	}
+	if other := sender.times["bsj00576"]; len(other) != 1 || other[0].Sub(start) > 100*time.Millisecond {
+		t.Fatalf("other device was delayed: %v", other)
+	}
+}
+
+func TestDispatcherCoalesce(t *testing.T) {
+	var mu sync.Mutex
+	var contents []string
+	var wg sync.WaitGroup
+	sender := senderFunc(func(request *requests.CommonRequest) {
+		mu.Lock()
+		contents = append(contents, request.BizContent["content"].(string))
+		mu.Unlock()
+	})
+	d, err := New(sender, Options{
+		Gap:      SpeechGap(0, 0),
+		Coalesce: &CoalesceOptions{Window: 50 * time.Millisecond, Threshold: 1000},
+		OnResult: func(r Result) {
+			if r.Status != StatusCoalesced {
+				wg.Done()
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	wg.Add(2)
+	for _, money := range []string{"100", "20.5", "8"} {
+		r := requests.NewPlayRequest("bsj00575", "")
+		r.Money = money
+		d.Enqueue(NewPlayJob(r))
+	}
+	large := requests.NewPlayRequest("bsj00575", "收款成功3467.91元")
+	large.Money = "3467.91"
+	d.Enqueue(NewPlayJob(large))
+	wg.Wait()
+	if len(contents) != 2 || contents[0] != "收款成功3467.91元" || contents[1] != "收到3笔，共计128.50元" {
+		t.Fatalf("unexpected announcements: %q", contents)
+	}
+}
+
+// senderFunc 以函数实现发送方，始终返回成功
+type senderFunc func(request *requests.CommonRequest)
+
+func (f senderFunc) ProcessCommonRequestWithContext(ctx context.Context, request *requests.CommonRequest) (*responses.CommonResponse, error) {
+	f(request)
+	response := responses.NewCommonResponse(nil, request)
+	response.SetHttpContent([]byte(`{"code":0}`), "string")
+	return response, nil
+}
+
+func TestParseCron(t *testing.T) {
+	s, err := ParseCron("TZ=Asia/Shanghai 30 10,14 * * 1-5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	// 2026-10-16 为周五
+	next := s.Next(time.Date(2026, 10, 16, 14, 30, 0, 0, loc))
+	if want := time.Date(2026, 10, 19, 10, 30, 0, 0, loc); !next.Equal(want) {
+		t.Fatalf("next = %v, want %v", next, want)
+	}
+	// 日以 * 开头时与周须同时满足：单数日且为周一
+	s, err = ParseCron("TZ=Asia/Shanghai 0 9 */2 * 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next = s.Next(time.Date(2026, 10, 19, 10, 0, 0, 0, loc))
+	if want := time.Date(2026, 11, 9, 9, 0, 0, 0, loc); !next.Equal(want) {
+		t.Fatalf("next = %v, want %v", next, want)
+	}
+	if _, err := ParseCron("*/0 * * * *"); err == nil {
+		t.Fatal("expected invalid step error")
+	}
+}
+
+func TestScheduleAndCancel(t *testing.T) {
+	d, err := New(senderFunc(func(*requests.CommonRequest) {}), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	at := time.Now().Add(time.Hour)
+	id, err := d.Schedule(at, NewPrintJob(requests.NewPrintRequest("bsj00576", "交班小票")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cronId, err := d.ScheduleCron("0 11 * * *", NewPlayJob(requests.NewPlayRequest("bsj00575", "午市即将开始")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jobs, _ := d.Pending(); len(jobs) != 2 {
+		t.Fatalf("unexpected pending jobs: %v", jobs)
+	}
+	if err := d.Cancel(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Cancel(id); err != ErrNotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if jobs, _ := d.Pending(); len(jobs) != 1 || jobs[0].ID != cronId || jobs[0].Cron == "" {
+		t.Fatalf("unexpected pending jobs: %v", jobs)
+	}
+}
+
+// failingStore 首次写入成功，之后写入均失败
+type failingStore struct {
+	*MemoryStore
+	puts int32
+}
+
+func (s *failingStore) Put(job *Job) error {
+	if atomic.AddInt32(&s.puts, 1) > 1 {
+		return errors.New("disk full")
+	}
+	return s.MemoryStore.Put(job)
+}
+
+func TestDispatcherReportsStoreErrors(t *testing.T) {
+	store := &failingStore{MemoryStore: NewMemoryStore()}
+	storeErrs := make(chan *StoreError, 4)
+	results := make(chan Result, 1)
+	d, err := New(&fakeSender{fail: 1}, Options{
+		Store:        store,
+		Backoff:      10 * time.Millisecond,
+		OnResult:     func(r Result) { results <- r },
+		OnStoreError: func(err *StoreError) { storeErrs <- err },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if _, err := d.Enqueue(NewPrintJob(requests.NewPrintRequest("bsj00576", "订单编号: 1200897812792015996"))); err != nil {
+		t.Fatal(err)
+	}
+	// 重试前写入失败应被报告，任务仍在内存中重试并最终送达
+	select {
+	case e := <-storeErrs:
+		if e.Op != "put" {
+			t.Fatalf("unexpected store error: %v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("store error not reported")
+	}
+	if r := <-results; r.Status != StatusDelivered {
+		t.Fatalf("unexpected result: %+v", r)
+	}
+	var storeErr *StoreError
+	if _, err := d.Enqueue(NewPrintJob(requests.NewPrintRequest("bsj00576", "交班小票"))); !errors.As(err, &storeErr) {
+		t.Fatalf("expected store error from Enqueue, got %v", err)
+	}
+	if jobs, _ := d.Pending(); len(jobs) != 0 {
+		t.Fatalf("unpersisted job pending: %v", jobs)
+	}
+}
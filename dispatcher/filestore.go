@@ -0,0 +1,110 @@
+package dispatcher
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/bigrocs/yxyiot/internal/wal"
+)
+
+// walRecord 预写日志记录
+type walRecord struct {
+	Op  string `json:"op"` // put 或 del
+	ID  string `json:"id,omitempty"`
+	Job *Job   `json:"job,omitempty"`
+}
+
+// FileStore 基于预写日志的文件存储，每次写入均落盘，进程重启后可恢复未完成的任务
+type FileStore struct {
+	mu   sync.Mutex
+	log  *wal.Log
+	jobs map[string]*Job
+}
+
+// OpenFileStore 打开或创建文件存储，并重放已有日志
+func OpenFileStore(path string) (*FileStore, error) {
+	s := &FileStore{jobs: make(map[string]*Job)}
+	log, err := wal.Open(path, s.apply, s.snapshot)
+	if err != nil {
+		return nil, err
+	}
+	s.log = log
+	return s, nil
+}
+
+// apply 重放一条日志记录，忽略崩溃时写入不完整的记录
+func (s *FileStore) apply(line []byte) {
+	var r walRecord
+	if err := json.Unmarshal(line, &r); err != nil {
+		return
+	}
+	switch r.Op {
+	case "put":
+		if r.Job != nil {
+			s.jobs[r.Job.ID] = r.Job
+		}
+	case "del":
+		delete(s.jobs, r.ID)
+	}
+}
+
+// snapshot 以当前任务重写日志
+func (s *FileStore) snapshot(write func(v interface{}) error) error {
+	for _, job := range sortedJobs(s.jobs) {
+		if err := write(walRecord{Op: "put", Job: job}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// append 追加一条记录并落盘，调用方需持有锁
+func (s *FileStore) append(r walRecord) error {
+	if s.log.Closed() {
+		return ErrClosed
+	}
+	return s.log.Append(r, len(s.jobs))
+}
+
+// Put 新增或更新任务
+func (s *FileStore) Put(job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job = job.clone()
+	prev, ok := s.jobs[job.ID]
+	s.jobs[job.ID] = job
+	if err := s.append(walRecord{Op: "put", Job: job}); err != nil {
+		if ok {
+			s.jobs[job.ID] = prev
+		} else {
+			delete(s.jobs, job.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete 删除任务
+func (s *FileStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[id]; !ok {
+		return nil
+	}
+	delete(s.jobs, id)
+	return s.append(walRecord{Op: "del", ID: id})
+}
+
+// List 列出所有任务，按创建时间排序
+func (s *FileStore) List() ([]*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sortedJobs(s.jobs), nil
+}
+
+// Close 关闭存储
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.log.Close()
+}
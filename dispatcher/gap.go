@@ -0,0 +1,40 @@
+package dispatcher
+
+import (
+	"encoding/json"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bigrocs/yxyiot/requests"
+)
+
+// 默认播报间隔估算参数
+const (
+	DefaultGapBase    = 500 * time.Millisecond // 每次播报的固定间隔
+	DefaultGapPerChar = 250 * time.Millisecond // 每个字的播报时长
+)
+
+// SpeechGap 按播报内容长度估算同一设备两次发送之间的间隔，打印任务不留间隔
+// 仅播报金额时按 "收款xx元" 估算
+func SpeechGap(base, perChar time.Duration) func(job *Job) time.Duration {
+	return func(job *Job) time.Duration {
+		text := ""
+		switch {
+		case job.Play != nil:
+			text = job.Play.Content
+			if text == "" {
+				text = "收款" + job.Play.Money + "元"
+			}
+		case job.Print != nil && job.Print.ActWay == requests.ActWayVoice:
+			var voice requests.PlayRequest
+			json.Unmarshal([]byte(job.Print.VoiceJson), &voice)
+			text = voice.Content
+			if text == "" {
+				text = "收款" + voice.Money + "元"
+			}
+		default:
+			return 0
+		}
+		return base + time.Duration(utf8.RuneCountInString(text))*perChar
+	}
+}
@@ -0,0 +1,141 @@
+package dispatcher
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/bigrocs/yxyiot/dedupe"
+	"github.com/bigrocs/yxyiot/requests"
+	uuid "github.com/satori/go.uuid"
+)
+
+// 任务类型
+const (
+	KindPlay  = "play"  // 云播报
+	KindPrint = "print" // 云打印
+)
+
+// Job 待发送的播报或打印任务
+type Job struct {
+	ID         string                 `json:"id"`                   // 任务ID，未指定 requestId 时作为平台 requestId
+	Kind       string                 `json:"kind"`                 // 任务类型
+	Play       *requests.PlayRequest  `json:"play,omitempty"`       // 播报请求
+	Print      *requests.PrintRequest `json:"print,omitempty"`      // 打印请求
+	Attempts   int                    `json:"attempts"`             // 已发送次数
+	NextAt     time.Time              `json:"nextAt"`               // 下次发送时间
+	CreatedAt  time.Time              `json:"createdAt"`            // 创建时间
+	LastError  string                 `json:"lastError,omitempty"`  // 最近一次失败原因
+	NoCoalesce bool                   `json:"noCoalesce,omitempty"` // 开启合并播报时仍单独播报
+	Coalesce   bool                   `json:"coalesce,omitempty"`   // 等待与同一设备的其他播报合并
+	Merged     []string               `json:"merged,omitempty"`     // 合并播报包含的任务ID
+	Cron       string                 `json:"cron,omitempty"`       // 周期计划，到期时生成一次性任务发送
+}
+
+// NewPlayJob 创建播报任务
+func NewPlayJob(request *requests.PlayRequest) *Job {
+	return &Job{Kind: KindPlay, Play: request}
+}
+
+// NewPrintJob 创建打印任务
+func NewPrintJob(request *requests.PrintRequest) *Job {
+	return &Job{Kind: KindPrint, Print: request}
+}
+
+// init 补全任务ID及创建时间
+func (j *Job) init(now time.Time) {
+	if j.ID == "" {
+		if key := j.BizKey(); key != "" {
+			j.ID = dedupe.RequestId(j.Kind, key) // 同一业务重复提交时覆盖原任务
+		} else {
+			j.ID = uuid.NewV4().String()
+		}
+	}
+	if j.CreatedAt.IsZero() {
+		j.CreatedAt = now
+	}
+	if j.NextAt.IsZero() {
+		j.NextAt = now
+	}
+}
+
+// BizKey 任务的业务去重键
+func (j *Job) BizKey() string {
+	switch {
+	case j.Play != nil:
+		return j.Play.BizKey
+	case j.Print != nil:
+		return j.Print.BizKey
+	}
+	return ""
+}
+
+// DevName 任务目标设备
+func (j *Job) DevName() string {
+	switch {
+	case j.Play != nil:
+		return j.Play.DevName
+	case j.Print != nil:
+		return j.Print.DevName
+	}
+	return ""
+}
+
+// CommonRequest 转换为公共请求，使用稳定的 requestId 以便重复投递时平台去重
+func (j *Job) CommonRequest() (*requests.CommonRequest, error) {
+	switch j.Kind {
+	case KindPlay:
+		if j.Play == nil {
+			return nil, fmt.Errorf("dispatcher: job %s has no play request", j.ID)
+		}
+		r := *j.Play
+		if r.RequestId == "" {
+			r.RequestId = j.ID
+		}
+		return r.CommonRequest(), nil
+	case KindPrint:
+		if j.Print == nil {
+			return nil, fmt.Errorf("dispatcher: job %s has no print request", j.ID)
+		}
+		r := *j.Print
+		if r.RequestId == "" {
+			r.RequestId = j.ID
+		}
+		return r.CommonRequest(), nil
+	}
+	return nil, fmt.Errorf("dispatcher: unknown job kind %q", j.Kind)
+}
+
+// occurrence 生成周期任务的单次执行任务，ID 及业务去重键按计划时间区分
+func (j *Job) occurrence(now time.Time) *Job {
+	o := j.clone()
+	suffix := "@" + strconv.FormatInt(j.NextAt.Unix(), 10)
+	o.ID = j.ID + suffix
+	o.Cron = ""
+	o.Attempts = 0
+	o.LastError = ""
+	o.NextAt = now
+	o.CreatedAt = now
+	if o.Play != nil && o.Play.BizKey != "" {
+		o.Play.BizKey += suffix
+	}
+	if o.Print != nil && o.Print.BizKey != "" {
+		o.Print.BizKey += suffix
+	}
+	return o
+}
+
+// clone 复制任务，避免存储与调用方共享数据
+func (j *Job) clone() *Job {
+	c := *j
+	if j.Play != nil {
+		p := *j.Play
+		c.Play = &p
+	}
+	if j.Print != nil {
+		p := *j.Print
+		c.Print = &p
+	}
+	c.Merged = append([]string(nil), j.Merged...)
+	return &c
+}
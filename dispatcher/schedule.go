@@ -0,0 +1,111 @@
+package dispatcher
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// 取消任务时的错误
+var (
+	ErrNotFound = errors.New("dispatcher: job not found")
+	ErrInFlight = errors.New("dispatcher: job is being delivered")
+)
+
+// Schedule 在指定时间发送任务，返回任务ID
+func (d *Dispatcher) Schedule(at time.Time, job *Job) (id string, err error) {
+	return d.submit(job, at)
+}
+
+// ScheduleCron 按 cron 表达式周期发送任务，返回周期任务ID，可通过 Cancel 停止
+// 每次到期生成ID为 "<周期任务ID>@<计划时间戳>" 的一次性任务
+func (d *Dispatcher) ScheduleCron(spec string, job *Job) (id string, err error) {
+	sched, err := ParseCron(spec)
+	if err != nil {
+		return "", err
+	}
+	next := sched.Next(time.Now())
+	if next.IsZero() {
+		return "", fmt.Errorf("dispatcher: cron spec %q never fires", spec)
+	}
+	job = job.clone()
+	job.Cron = spec
+	return d.submit(job, next)
+}
+
+// fire 周期任务到期：发送一次性任务并计算下次执行时间
+func (d *Dispatcher) fire(job *Job) {
+	now := time.Now()
+	occ := job.occurrence(now)
+	sched, err := ParseCron(job.Cron)
+	if err == nil {
+		job.NextAt = sched.Next(now)
+	}
+	if err != nil || job.NextAt.IsZero() {
+		d.finish(job, StatusFailed, nil, fmt.Errorf("dispatcher: cron %q has no next run", job.Cron))
+	} else if d.put(job) != nil {
+		job.NextAt = now.Add(time.Minute) // 写入失败时稍后重试
+	}
+	if d.put(occ) != nil {
+		occ = nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return
+	}
+	if occ != nil && !d.active[occ.ID] {
+		d.active[occ.ID] = true
+		d.dispatch(occ)
+	}
+	if !job.NextAt.IsZero() && d.active[job.ID] {
+		heap.Push(&d.queue, job)
+	}
+}
+
+// Cancel 取消尚未发送的任务或周期任务，发送中的任务返回 ErrInFlight
+func (d *Dispatcher) Cancel(id string) error {
+	d.mu.Lock()
+	if !d.active[id] {
+		d.mu.Unlock()
+		return ErrNotFound
+	}
+	var job *Job
+	for i, j := range d.queue {
+		if j.ID == id {
+			job = heap.Remove(&d.queue, i).(*Job)
+			break
+		}
+	}
+	for _, l := range d.lanes {
+		if job != nil {
+			break
+		}
+		for i, j := range l.jobs {
+			if j.ID == id {
+				job = j
+				l.jobs = append(l.jobs[:i], l.jobs[i+1:]...)
+				break
+			}
+		}
+	}
+	if job == nil {
+		d.mu.Unlock()
+		return ErrInFlight
+	}
+	d.mu.Unlock()
+	d.finish(job, StatusCanceled, nil, nil)
+	return nil
+}
+
+// Pending 列出未结束的任务（含周期任务），按下次发送时间排序
+func (d *Dispatcher) Pending() ([]*Job, error) {
+	jobs, err := d.opts.Store.List()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(jobs, func(i, j int) bool { return jobs[i].NextAt.Before(jobs[j].NextAt) })
+	return jobs, nil
+}
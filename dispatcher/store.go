@@ -0,0 +1,66 @@
+package dispatcher
+
+import (
+	"sort"
+	"sync"
+)
+
+// Store 任务持久化存储
+type Store interface {
+	Put(job *Job) error     // 新增或更新任务
+	Delete(id string) error // 删除已结束的任务
+	List() ([]*Job, error)  // 列出所有未结束的任务
+	Close() error           // 关闭存储
+}
+
+// MemoryStore 内存存储，进程重启后任务丢失，适用于测试
+type MemoryStore struct {
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+// NewMemoryStore 创建内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{jobs: make(map[string]*Job)}
+}
+
+// Put 新增或更新任务
+func (s *MemoryStore) Put(job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = job.clone()
+	return nil
+}
+
+// Delete 删除任务
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.jobs, id)
+	return nil
+}
+
+// List 列出所有任务，按创建时间排序
+func (s *MemoryStore) List() ([]*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sortedJobs(s.jobs), nil
+}
+
+// Close 关闭存储
+func (s *MemoryStore) Close() error { return nil }
+
+// sortedJobs 复制并按创建时间排序
+func sortedJobs(jobs map[string]*Job) []*Job {
+	list := make([]*Job, 0, len(jobs))
+	for _, j := range jobs {
+		list = append(list, j.clone())
+	}
+	sort.Slice(list, func(a, b int) bool {
+		if list[a].CreatedAt.Equal(list[b].CreatedAt) {
+			return list[a].ID < list[b].ID
+		}
+		return list[a].CreatedAt.Before(list[b].CreatedAt)
+	})
+	return list
+}
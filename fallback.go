@@ -0,0 +1,170 @@
+package yxyiot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/bigrocs/yxyiot/breaker"
+	"github.com/bigrocs/yxyiot/registry"
+	"github.com/bigrocs/yxyiot/requests"
+	"github.com/bigrocs/yxyiot/responses"
+)
+
+// 送达路径
+const (
+	RouteSpeaker      = "speaker"       // 云喇叭播报（play）
+	RoutePrinterVoice = "printer_voice" // 打印机播报（print，actWay=2）
+	RoutePrinterSlip  = "printer_slip"  // 打印小票（print，actWay=1）
+)
+
+// 默认备用路径
+var (
+	DefaultPlayRoutes  = []string{RouteSpeaker, RoutePrinterVoice, RoutePrinterSlip}
+	DefaultPrintRoutes = []string{RoutePrinterSlip, RouteSpeaker}
+)
+
+// DefaultPrinterNotice 打印失败改由云喇叭播报时的默认提示
+const DefaultPrinterNotice = "打印机离线，请及时查看订单"
+
+// StoreProfile 门店设备配置及备用路径
+type StoreProfile struct {
+	StoreId       string   `json:"storeId"`       // 门店编号
+	Speaker       string   `json:"speaker"`       // 云喇叭设备
+	Printer       string   `json:"printer"`       // 打印机设备
+	PlayRoutes    []string `json:"playRoutes"`    // 播报依次尝试的路径，默认 DefaultPlayRoutes
+	PrintRoutes   []string `json:"printRoutes"`   // 打印依次尝试的路径，默认 DefaultPrintRoutes
+	PrinterNotice string   `json:"printerNotice"` // 打印改为播报时的提示，默认 DefaultPrinterNotice
+}
+
+// RouteAttempt 单条路径的尝试结果
+type RouteAttempt struct {
+	Route   string
+	DevName string
+	Err     error
+}
+
+// RouteResult 按备用路径送达的结果
+type RouteResult struct {
+	Route    string // 最终送达的路径，全部失败时为空
+	DevName  string // 最终送达的设备
+	Response *responses.CommonResponse
+	Attempts []RouteAttempt // 依次尝试的路径
+}
+
+// ErrNoRoute 门店未配置可用的送达路径
+var ErrNoRoute = errors.New("yxyiot: no route available for store")
+
+// PlayWithFallback 向门店播报，云喇叭离线或熔断时按门店配置改由打印机播报或打印小票
+func (client *Client) PlayWithFallback(ctx context.Context, profile *StoreProfile, request *requests.PlayRequest) (*RouteResult, error) {
+	routes := profile.PlayRoutes
+	if len(routes) == 0 {
+		routes = DefaultPlayRoutes
+	}
+	text := request.Content
+	if text == "" {
+		text = "收款" + request.Money + "元"
+	}
+	return client.route(ctx, profile, routes, request.RequestId, request.BizKey, func(route string) (*requests.CommonRequest, string) {
+		switch route {
+		case RouteSpeaker:
+			r := *request
+			r.DevName = profile.Speaker
+			return r.CommonRequest(), profile.Speaker
+		case RoutePrinterVoice:
+			return voicePrintRequest(profile.Printer, request).CommonRequest(), profile.Printer
+		case RoutePrinterSlip:
+			return requests.NewPrintRequest(profile.Printer, text+"<BR>").CommonRequest(), profile.Printer
+		}
+		return nil, ""
+	})
+}
+
+// PrintWithFallback 向门店打印，打印机离线或熔断时按门店配置改由云喇叭播报提示
+func (client *Client) PrintWithFallback(ctx context.Context, profile *StoreProfile, request *requests.PrintRequest) (*RouteResult, error) {
+	routes := profile.PrintRoutes
+	if len(routes) == 0 {
+		routes = DefaultPrintRoutes
+	}
+	notice := profile.PrinterNotice
+	if notice == "" {
+		notice = DefaultPrinterNotice
+	}
+	return client.route(ctx, profile, routes, request.RequestId, request.BizKey, func(route string) (*requests.CommonRequest, string) {
+		switch route {
+		case RoutePrinterSlip, RoutePrinterVoice:
+			r := *request
+			r.DevName = profile.Printer
+			return r.CommonRequest(), profile.Printer
+		case RouteSpeaker:
+			return requests.NewPlayRequest(profile.Speaker, notice).CommonRequest(), profile.Speaker
+		}
+		return nil, ""
+	})
+}
+
+// route 依次尝试各路径，设备离线、不存在（responses.DeviceUnavailableCodes）、熔断或不支持该路径时尝试下一条路径
+func (client *Client) route(ctx context.Context, profile *StoreProfile, routes []string, requestId, bizKey string, build func(route string) (*requests.CommonRequest, string)) (*RouteResult, error) {
+	result := &RouteResult{}
+	var err error
+	for _, route := range routes {
+		request, devName := build(route)
+		if request == nil || devName == "" {
+			continue
+		}
+		if requestId != "" {
+			request.BizContent["requestId"] = requestId + "-" + route
+		}
+		if bizKey != "" {
+			request.BizKey = bizKey + "-" + route
+		}
+		var response *responses.CommonResponse
+		response, err = client.process(ctx, request)
+		result.Attempts = append(result.Attempts, RouteAttempt{Route: route, DevName: devName, Err: err})
+		if err == nil {
+			result.Route, result.DevName, result.Response = route, devName, response
+			return result, nil
+		}
+		if !deviceUnavailable(err) {
+			return result, err
+		}
+	}
+	if err == nil {
+		err = fmt.Errorf("%w: %s", ErrNoRoute, profile.StoreId)
+	}
+	return result, err
+}
+
+// deviceUnavailable 判断失败是否由设备不可用导致，可改用其他设备；签名、参数等其他业务错误直接返回
+func deviceUnavailable(err error) bool {
+	var (
+		apiErr *responses.APIError
+		open   *breaker.OpenError
+		capErr *registry.CapabilityError
+	)
+	if errors.As(err, &apiErr) {
+		return apiErr.DeviceUnavailable()
+	}
+	return errors.As(err, &capErr) || (errors.As(err, &open) && open.Key.Kind == breaker.KindDevice)
+}
+
+// voicePrintRequest 将播报请求转换为打印机播报请求
+func voicePrintRequest(printer string, request *requests.PlayRequest) *requests.PrintRequest {
+	voice := map[string]string{"devName": printer, "bizType": request.BizType}
+	for k, v := range map[string]string{
+		"content":       request.Content,
+		"money":         request.Money,
+		"broadCastType": request.BroadCastType,
+	} {
+		if v != "" {
+			voice[k] = v
+		}
+	}
+	b, _ := json.Marshal(voice)
+	return &requests.PrintRequest{
+		DevName:   printer,
+		ActWay:    requests.ActWayVoice,
+		VoiceJson: string(b),
+	}
+}
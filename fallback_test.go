@@ -0,0 +1,44 @@
+package yxyiot
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bigrocs/yxyiot/requests"
+	"github.com/bigrocs/yxyiot/responses"
+)
+
+func TestPlayWithFallback(t *testing.T) {
+	defer func(codes []string) { responses.DeviceUnavailableCodes = codes }(responses.DeviceUnavailableCodes)
+	responses.DeviceUnavailableCodes = []string{"1001"}
+	client := NewClient()
+	client.Use(fakeTransport(map[string]string{"bsj00575": `{"code":1001,"msg":"device offline"}`}))
+	profile := &StoreProfile{StoreId: "123", Speaker: "bsj00575", Printer: "bsj00576"}
+	res, err := client.PlayWithFallback(context.Background(), profile, requests.NewPlayRequest("", "张三收款成功3467.91元"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Route != RoutePrinterVoice || res.DevName != "bsj00576" || len(res.Attempts) != 2 {
+		t.Fatalf("unexpected route: %+v", res)
+	}
+	if res.Response.Request.BizContent["actWay"] != requests.ActWayVoice {
+		t.Fatalf("unexpected request: %v", res.Response.Request.BizContent)
+	}
+}
+
+func TestPlayWithFallbackReturnsOtherAPIErrors(t *testing.T) {
+	defer func(codes []string) { responses.DeviceUnavailableCodes = codes }(responses.DeviceUnavailableCodes)
+	responses.DeviceUnavailableCodes = []string{"1001"}
+	client := NewClient()
+	client.Use(fakeTransport(map[string]string{"bsj00575": `{"code":4001,"msg":"sign error"}`}))
+	profile := &StoreProfile{StoreId: "123", Speaker: "bsj00575", Printer: "bsj00576"}
+	res, err := client.PlayWithFallback(context.Background(), profile, requests.NewPlayRequest("", "张三收款成功3467.91元"))
+	var apiErr *responses.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "4001" {
+		t.Fatalf("expected api error 4001, got %v", err)
+	}
+	if res.Route != "" || len(res.Attempts) != 1 {
+		t.Fatalf("unexpected route: %+v", res)
+	}
+}
@@ -0,0 +1,42 @@
+package yxyiot
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/bigrocs/yxyiot/requests"
+	"github.com/bigrocs/yxyiot/responses"
+	"github.com/bigrocs/yxyiot/util"
+)
+
+// metricsStart 记录请求发起
+func (client *Client) metricsStart(request *requests.CommonRequest) {
+	if client.Metrics == nil {
+		return
+	}
+	client.Metrics.IncRequest(request.ApiName, util.InterfaceToString(request.BizContent["devName"]))
+}
+
+// metricsFinish 记录请求结果、重试次数及耗时
+func (client *Client) metricsFinish(request *requests.CommonRequest, response *responses.CommonResponse, err error, st *callState, elapsed time.Duration) {
+	m := client.Metrics
+	if m == nil {
+		return
+	}
+	api := request.ApiName
+	devName := util.InterfaceToString(request.BizContent["devName"])
+	for i := int32(1); i < atomic.LoadInt32(&st.attempts); i++ {
+		m.IncRetry(api, devName)
+	}
+	switch Outcome(response, err) {
+	case OutcomeSuccess:
+		m.IncSuccess(api, devName)
+	case OutcomeAPIError:
+		m.IncAPIError(api, devName, response.GetAPIError().Code)
+	case OutcomeRejected:
+		m.IncRejected(api, devName, RejectReason(err))
+	default:
+		m.IncTransportError(api, devName)
+	}
+	m.ObserveLatency(api, devName, elapsed)
+}
@@ -0,0 +1,174 @@
+package yxyiot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bigrocs/yxyiot/dedupe"
+	"github.com/bigrocs/yxyiot/requests"
+	"github.com/bigrocs/yxyiot/responses"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Handler 请求处理函数
+type Handler func(ctx context.Context, request *requests.CommonRequest, response *responses.CommonResponse) error
+
+// Interceptor 请求拦截器，包装下一个处理函数
+type Interceptor func(next Handler) Handler
+
+// Chain 将多个拦截器组合为一个，第一个位于最外层
+func Chain(interceptors ...Interceptor) Interceptor {
+	return func(next Handler) Handler {
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			next = interceptors[i](next)
+		}
+		return next
+	}
+}
+
+// WithDefaults 为请求补充缺省业务参数，已存在的参数不会被覆盖
+func WithDefaults(fields map[string]interface{}) Interceptor {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request *requests.CommonRequest, response *responses.CommonResponse) error {
+			r := cloneRequest(request)
+			for k, v := range fields {
+				if _, ok := r.BizContent[k]; !ok {
+					r.BizContent[k] = v
+				}
+			}
+			response.Request = r
+			return next(ctx, r, response)
+		}
+	}
+}
+
+// Skip 当 match 返回错误时直接返回该错误，不再发送请求
+func Skip(match func(ctx context.Context, request *requests.CommonRequest) error) Interceptor {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request *requests.CommonRequest, response *responses.CommonResponse) error {
+			if err := match(ctx, request); err != nil {
+				return err
+			}
+			return next(ctx, request, response)
+		}
+	}
+}
+
+// Dedupe 按请求的 BizKey 去重：生成稳定的 requestId，有效期内重复的请求返回 dedupe.ErrDuplicate
+// 请求失败时清除记录以便重新发送；应注册在 Retry 之前（外层），未设置 BizKey 的请求不受影响
+func Dedupe(store dedupe.Store, ttl time.Duration) Interceptor {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request *requests.CommonRequest, response *responses.CommonResponse) error {
+			if request.BizKey == "" {
+				return next(ctx, request, response)
+			}
+			key := request.ApiName + ":" + request.BizKey
+			ok, err := store.SetIfAbsent(key, ttl)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return dedupe.ErrDuplicate
+			}
+			if _, exists := request.BizContent["requestId"]; !exists {
+				request = cloneRequest(request)
+				request.BizContent["requestId"] = dedupe.RequestId(request.ApiName, request.BizKey)
+				response.Request = request
+			}
+			err = next(ctx, request, response)
+			if err != nil || response.GetAPIError() != nil {
+				store.Delete(key)
+			}
+			return err
+		}
+	}
+}
+
+// Timeout 为单次调用设置超时时间
+func Timeout(d time.Duration) Interceptor {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request *requests.CommonRequest, response *responses.CommonResponse) error {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			return next(ctx, request, response)
+		}
+	}
+}
+
+// Retry 发生传输错误时重试，最多执行 attempts 次，等待时间从 backoff 开始逐次翻倍
+// 被播报策略、去重或熔断拒绝的请求不会重试
+// 请求未携带 requestId 时会先生成一个，保证重试时平台可据此去重
+func Retry(attempts int, backoff time.Duration) Interceptor {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request *requests.CommonRequest, response *responses.CommonResponse) (err error) {
+			if _, ok := request.BizContent["requestId"]; !ok {
+				request = cloneRequest(request)
+				request.BizContent["requestId"] = uuid.NewV4().String()
+				response.Request = request
+			}
+			wait := backoff
+			for i := 0; i < attempts || i == 0; i++ {
+				if i > 0 {
+					timer := time.NewTimer(wait)
+					select {
+					case <-ctx.Done():
+						timer.Stop()
+						return err
+					case <-timer.C:
+					}
+					wait *= 2
+				}
+				if err = next(ctx, request, response); err == nil || ctx.Err() != nil || !retryable(err) {
+					return err
+				}
+			}
+			return err
+		}
+	}
+}
+
+// retryable 判断错误是否值得重试，被策略、去重或熔断拒绝的请求立即返回，限流可等待后重试
+func retryable(err error) bool {
+	switch RejectReason(err) {
+	case "", RejectRateLimit:
+		return true
+	}
+	return false
+}
+
+// Recover 将处理过程中的 panic 转换为错误
+func Recover() Interceptor {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request *requests.CommonRequest, response *responses.CommonResponse) (err error) {
+			defer func() {
+				if x := recover(); x != nil {
+					err = fmt.Errorf("yxyiot: panic in %s: %v", request.ApiName, x)
+				}
+			}()
+			return next(ctx, request, response)
+		}
+	}
+}
+
+// Observe 在每次调用结束后回调，可用于日志、监控及链路追踪
+func Observe(fn func(ctx context.Context, request *requests.CommonRequest, response *responses.CommonResponse, err error, elapsed time.Duration)) Interceptor {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request *requests.CommonRequest, response *responses.CommonResponse) error {
+			start := time.Now()
+			err := next(ctx, request, response)
+			fn(ctx, request, response, err, time.Since(start))
+			return err
+		}
+	}
+}
+
+// cloneRequest 复制请求及其业务参数，避免修改调用方的数据
+func cloneRequest(request *requests.CommonRequest) *requests.CommonRequest {
+	r := *request
+	r.BizContent = make(map[string]interface{}, len(request.BizContent)+1)
+	for k, v := range request.BizContent {
+		r.BizContent[k] = v
+	}
+	return &r
+}
@@ -0,0 +1,87 @@
+package yxyiot
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bigrocs/yxyiot/dedupe"
+	"github.com/bigrocs/yxyiot/metrics"
+	"github.com/bigrocs/yxyiot/requests"
+	"github.com/bigrocs/yxyiot/responses"
+)
+
+func TestChain(t *testing.T) {
+	var calls []string
+	mark := func(name string) Interceptor {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, request *requests.CommonRequest, response *responses.CommonResponse) error {
+				calls = append(calls, name)
+				return next(ctx, request, response)
+			}
+		}
+	}
+	attempts := 0
+	h := Chain(mark("a"), WithDefaults(map[string]interface{}{"bizType": "2"}), Retry(3, time.Millisecond), mark("b"))(
+		func(ctx context.Context, request *requests.CommonRequest, response *responses.CommonResponse) error {
+			attempts++
+			if request.BizContent["bizType"] != "2" || request.BizContent["requestId"] == nil {
+				t.Fatalf("unexpected biz content: %v", request.BizContent)
+			}
+			if attempts < 3 {
+				return errors.New("transport error")
+			}
+			return nil
+		})
+	request := requests.NewCommonRequest()
+	request.BizContent = map[string]interface{}{"devName": "bsj00575"}
+	if err := h(context.Background(), request, &responses.CommonResponse{}); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 3 || len(calls) != 4 || calls[0] != "a" {
+		t.Fatalf("attempts=%d calls=%v", attempts, calls)
+	}
+	if _, ok := request.BizContent["bizType"]; ok {
+		t.Fatal("caller request was mutated")
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	var requestIds []interface{}
+	h := Dedupe(dedupe.NewMemoryStore(), time.Hour)(
+		func(ctx context.Context, request *requests.CommonRequest, response *responses.CommonResponse) error {
+			requestIds = append(requestIds, request.BizContent["requestId"])
+			return nil
+		})
+	play := requests.NewPlayRequest("bsj00575", "张三收款成功3467.91元")
+	play.BizKey = "1200897812792015996"
+	for i := 0; i < 2; i++ {
+		err := h(context.Background(), play.CommonRequest(), &responses.CommonResponse{})
+		if i == 1 && err != dedupe.ErrDuplicate {
+			t.Fatalf("expected duplicate, got %v", err)
+		}
+	}
+	if len(requestIds) != 1 || requestIds[0] != dedupe.RequestId("play", play.BizKey) {
+		t.Fatalf("unexpected requestIds: %v", requestIds)
+	}
+}
+
+func TestRejectedOutcome(t *testing.T) {
+	m := metrics.NewMemory()
+	client := NewClient()
+	client.Metrics = m
+	client.Use(Dedupe(dedupe.NewMemoryStore(), time.Hour), fakeTransport(nil))
+	play := requests.NewPlayRequest("bsj00575", "张三收款成功3467.91元")
+	play.BizKey = "1200897812792015996"
+	for i := 0; i < 2; i++ {
+		_, err := client.ProcessCommonRequest(play.CommonRequest())
+		if i == 1 && Outcome(nil, err) != OutcomeRejected {
+			t.Fatalf("expected rejected outcome, got %v", err)
+		}
+	}
+	c := m.Counters("play", "bsj00575")
+	if c.Successes != 1 || c.Rejected[RejectDuplicate] != 1 || c.TransportErrors != 0 {
+		t.Fatalf("unexpected counters: %+v", c)
+	}
+}
@@ -0,0 +1,122 @@
+package wal
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+)
+
+// Snapshot 以 write 写出当前全部有效记录，用于重写日志
+type Snapshot func(write func(v interface{}) error) error
+
+// Log 追加写日志，每次写入均落盘，无效记录过多时以快照重写
+type Log struct {
+	path     string
+	f        *os.File
+	records  int
+	snapshot Snapshot
+}
+
+// Open 重放 path 中的日志后以快照重写并打开，apply 依次接收每行记录，
+// 崩溃时写入不完整的记录由调用方解析失败后忽略
+func Open(path string, apply func(line []byte), snapshot Snapshot) (*Log, error) {
+	l := &Log{path: path, snapshot: snapshot}
+	if err := l.replay(apply); err != nil {
+		return nil, err
+	}
+	if err := l.Compact(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// replay 重放日志
+func (l *Log) replay(apply func(line []byte)) error {
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		apply(scanner.Bytes())
+	}
+	return scanner.Err()
+}
+
+// Compact 以快照重写日志，调用方需持有锁或处于初始化阶段
+func (l *Log) Compact() error {
+	tmp := l.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	n := 0
+	err = l.snapshot(func(v interface{}) error {
+		n++
+		return enc.Encode(v)
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if l.f != nil {
+		l.f.Close()
+		l.f = nil
+	}
+	if err = os.Rename(tmp, l.path); err != nil {
+		return err
+	}
+	l.f, err = os.OpenFile(l.path, os.O_APPEND|os.O_WRONLY, 0600)
+	l.records = n
+	return err
+}
+
+// Append 追加一条记录并落盘，live 为当前有效记录数，日志记录超过其两倍时重写；调用方需持有锁
+func (l *Log) Append(v interface{}, live int) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err = l.f.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	if err = l.f.Sync(); err != nil {
+		return err
+	}
+	l.records++
+	if l.records > 2*live+1024 {
+		return l.Compact()
+	}
+	return nil
+}
+
+// Closed 日志是否已关闭
+func (l *Log) Closed() bool {
+	return l.f == nil
+}
+
+// Close 关闭日志，重复关闭返回 nil
+func (l *Log) Close() error {
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
@@ -0,0 +1,38 @@
+package wal
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLogReplayAndCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.wal")
+	if err := ioutil.WriteFile(path, []byte("\"a\"\n\"b\"\n{\"trunc"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	l, err := Open(path, func(line []byte) { lines = append(lines, string(line)) }, func(write func(v interface{}) error) error {
+		return write("b")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(lines, ",") != `"a","b",{"trunc` {
+		t.Fatalf("unexpected replay: %v", lines)
+	}
+	if err = l.Append("c", 2); err != nil {
+		t.Fatal(err)
+	}
+	if err = l.Close(); err != nil || !l.Closed() {
+		t.Fatalf("close: %v", err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "\"b\"\n\"c\"\n" {
+		t.Fatalf("unexpected log: %q", b)
+	}
+}
@@ -0,0 +1,187 @@
+package yxyiot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bigrocs/yxyiot/breaker"
+	"github.com/bigrocs/yxyiot/common"
+	"github.com/bigrocs/yxyiot/dedupe"
+	"github.com/bigrocs/yxyiot/policy"
+	"github.com/bigrocs/yxyiot/ratelimit"
+	"github.com/bigrocs/yxyiot/registry"
+	"github.com/bigrocs/yxyiot/requests"
+	"github.com/bigrocs/yxyiot/responses"
+	"github.com/bigrocs/yxyiot/util"
+)
+
+// Logger 结构化日志接口，args 为交替的键值对，可直接使用 *slog.Logger
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// LogLevel 日志详细程度
+type LogLevel int
+
+const (
+	LogLevelInfo  LogLevel = iota // 记录每次请求的结果（默认）
+	LogLevelDebug                 // 额外记录请求开始及脱敏后的业务参数
+	LogLevelError                 // 仅记录失败的请求
+	LogLevelOff                   // 不记录
+)
+
+// Redactor 日志脱敏函数，返回参数在日志中的展示值
+type Redactor func(key string, value interface{}) interface{}
+
+// DefaultRedactor 默认脱敏：隐藏 token、AppSecret，播报及打印内容仅记录长度以免泄露付款人姓名
+func DefaultRedactor(key string, value interface{}) interface{} {
+	if util.IsSensitiveKey(key) {
+		return util.RedactedValue
+	}
+	if util.IsPersonalKey(key) {
+		return fmt.Sprintf("[%d chars]", utf8.RuneCountInString(fmt.Sprint(value)))
+	}
+	return value
+}
+
+// 调用结果
+const (
+	OutcomeSuccess  = "success"   // 请求成功
+	OutcomeAPIError = "api_error" // 平台返回业务错误
+	OutcomeRejected = "rejected"  // 被去重、策略、设备能力校验、熔断或限流在本地拒绝，未发送到平台
+	OutcomeError    = "error"     // 传输错误或其他拦截器错误
+)
+
+// 本地拒绝原因
+const (
+	RejectDuplicate  = "duplicate"  // 重复请求被去重
+	RejectPolicy     = "policy"     // 被播报策略丢弃或延后
+	RejectCapability = "capability" // 超出设备能力
+	RejectBreaker    = "breaker"    // 设备或接口熔断中
+	RejectRateLimit  = "ratelimit"  // 超出限流配置
+)
+
+// RejectReason 返回请求被本地拒绝的原因，非本地拒绝时返回空字符串
+func RejectReason(err error) string {
+	var (
+		dropped  *policy.DroppedError
+		deferred *policy.DeferredError
+		open     *breaker.OpenError
+		limited  *ratelimit.LimitError
+		capErr   *registry.CapabilityError
+	)
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, dedupe.ErrDuplicate):
+		return RejectDuplicate
+	case errors.As(err, &dropped), errors.As(err, &deferred):
+		return RejectPolicy
+	case errors.As(err, &capErr):
+		return RejectCapability
+	case errors.As(err, &open):
+		return RejectBreaker
+	case errors.As(err, &limited):
+		return RejectRateLimit
+	}
+	return ""
+}
+
+// Outcome 归类一次调用的结果
+func Outcome(response *responses.CommonResponse, err error) string {
+	var apiErr *responses.APIError
+	if errors.As(err, &apiErr) {
+		return OutcomeAPIError
+	}
+	if RejectReason(err) != "" {
+		return OutcomeRejected
+	}
+	if err != nil {
+		return OutcomeError
+	}
+	if response != nil && response.GetAPIError() != nil {
+		return OutcomeAPIError
+	}
+	return OutcomeSuccess
+}
+
+// callStateKey 调用状态在上下文中的键
+type callStateKey struct{}
+
+// callState 单次调用的执行状态
+type callState struct {
+	attempts int32
+}
+
+// addAttempt 记录一次实际发送
+func addAttempt(ctx context.Context) {
+	if st, ok := ctx.Value(callStateKey{}).(*callState); ok {
+		atomic.AddInt32(&st.attempts, 1)
+	}
+}
+
+// logStart 记录请求开始
+func (client *Client) logStart(request *requests.CommonRequest) {
+	if client.Logger == nil || client.LogLevel != LogLevelDebug {
+		return
+	}
+	client.Logger.Debug("yxyiot request start", append(client.logFields(request), "params", client.redactParams(request.BizContent))...)
+}
+
+// logFinish 记录请求结果
+func (client *Client) logFinish(request *requests.CommonRequest, response *responses.CommonResponse, err error, st *callState, elapsed time.Duration) {
+	if client.Logger == nil || client.LogLevel == LogLevelOff {
+		return
+	}
+	outcome := Outcome(response, err)
+	if outcome == OutcomeSuccess && client.LogLevel == LogLevelError {
+		return
+	}
+	args := append(client.logFields(request),
+		"duration", elapsed,
+		"attempts", atomic.LoadInt32(&st.attempts),
+		"outcome", outcome,
+	)
+	switch outcome {
+	case OutcomeSuccess:
+		client.Logger.Info("yxyiot request finished", args...)
+	case OutcomeAPIError:
+		e := response.GetAPIError()
+		client.Logger.Warn("yxyiot request finished", append(args, "code", e.Code, "msg", e.Message)...)
+	case OutcomeRejected:
+		client.Logger.Warn("yxyiot request finished", append(args, "reason", RejectReason(err), "error", err.Error())...)
+	default:
+		client.Logger.Error("yxyiot request finished", append(args, "error", util.RedactError(err, client.Config.AppSecret).Error())...)
+	}
+}
+
+// logFields 请求的公共日志字段
+func (client *Client) logFields(request *requests.CommonRequest) []interface{} {
+	u := &common.Common{Config: client.Config, Requests: request}
+	return []interface{}{
+		"api", request.ApiName,
+		"endpoint", u.Endpoint(),
+		"devName", util.InterfaceToString(request.BizContent["devName"]),
+		"requestId", util.InterfaceToString(request.BizContent["requestId"]),
+	}
+}
+
+// redactParams 返回脱敏后的业务参数
+func (client *Client) redactParams(params map[string]interface{}) map[string]interface{} {
+	redact := client.LogRedactor
+	if redact == nil {
+		redact = DefaultRedactor
+	}
+	m := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		m[k] = redact(k, v)
+	}
+	return m
+}
@@ -0,0 +1,54 @@
+package yxyiot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bigrocs/yxyiot/requests"
+	"github.com/bigrocs/yxyiot/responses"
+)
+
+type recordLogger struct{ lines []string }
+
+func (l *recordLogger) log(level, msg string, args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprint(level, " ", msg, " ", args))
+}
+func (l *recordLogger) Debug(msg string, args ...interface{}) { l.log("DEBUG", msg, args...) }
+func (l *recordLogger) Info(msg string, args ...interface{})  { l.log("INFO", msg, args...) }
+func (l *recordLogger) Warn(msg string, args ...interface{})  { l.log("WARN", msg, args...) }
+func (l *recordLogger) Error(msg string, args ...interface{}) { l.log("ERROR", msg, args...) }
+
+func TestLogRedaction(t *testing.T) {
+	logger := &recordLogger{}
+	client := NewClient()
+	client.Logger = logger
+	client.LogLevel = LogLevelDebug
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, request *requests.CommonRequest, response *responses.CommonResponse) error {
+			response.SetHttpContent([]byte(`{"code":1001,"msg":"device offline"}`), "string")
+			return nil
+		}
+	})
+	request := requests.NewCommonRequest()
+	request.ApiName = "play"
+	request.BizContent = map[string]interface{}{
+		"devName": "bsj00575",
+		"content": "张三收款成功3467.91元",
+		"token":   "SECRET",
+	}
+	if _, err := client.ProcessCommonRequest(request); err != nil {
+		t.Fatal(err)
+	}
+	if len(logger.lines) != 2 || !strings.HasPrefix(logger.lines[1], "WARN") {
+		t.Fatalf("unexpected log lines: %q", logger.lines)
+	}
+	out := strings.Join(logger.lines, "\n")
+	if strings.Contains(out, "张三") || strings.Contains(out, "SECRET") {
+		t.Fatalf("log not redacted: %s", out)
+	}
+	if !strings.Contains(out, "api_error") || !strings.Contains(out, "bsj00575") {
+		t.Fatalf("log missing fields: %s", out)
+	}
+}
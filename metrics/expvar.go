@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"expvar"
+	"sync"
+	"time"
+)
+
+// Expvar 基于 expvar 的指标导出，按 "api:<接口>" 与 "dev:<设备>" 分别统计，
+// 可通过 /debug/vars 查看
+type Expvar struct {
+	requests        *expvar.Map
+	successes       *expvar.Map
+	apiErrors       *expvar.Map
+	transportErrors *expvar.Map
+	rejected        *expvar.Map
+	retries         *expvar.Map
+
+	mu        sync.Mutex
+	buckets   []time.Duration
+	latencies map[string]*Histogram
+}
+
+// NewExpvar 创建并以 name 发布指标，同一名称只能发布一次
+func NewExpvar(name string, buckets ...time.Duration) *Expvar {
+	e := &Expvar{
+		requests:        new(expvar.Map).Init(),
+		successes:       new(expvar.Map).Init(),
+		apiErrors:       new(expvar.Map).Init(),
+		transportErrors: new(expvar.Map).Init(),
+		rejected:        new(expvar.Map).Init(),
+		retries:         new(expvar.Map).Init(),
+		buckets:         buckets,
+		latencies:       make(map[string]*Histogram),
+	}
+	root := expvar.NewMap(name)
+	root.Set("requests", e.requests)
+	root.Set("successes", e.successes)
+	root.Set("api_errors", e.apiErrors)
+	root.Set("transport_errors", e.transportErrors)
+	root.Set("rejected", e.rejected)
+	root.Set("retries", e.retries)
+	root.Set("latency", expvar.Func(e.latencySnapshot))
+	return e
+}
+
+// keys 指标维度对应的键
+func keys(api, devName string) []string {
+	if devName == "" {
+		return []string{"api:" + api}
+	}
+	return []string{"api:" + api, "dev:" + devName}
+}
+
+// add 为各维度计数加一
+func add(m *expvar.Map, api, devName, suffix string) {
+	for _, k := range keys(api, devName) {
+		m.Add(k+suffix, 1)
+	}
+}
+
+// IncRequest 发起请求
+func (e *Expvar) IncRequest(api, devName string) { add(e.requests, api, devName, "") }
+
+// IncSuccess 请求成功
+func (e *Expvar) IncSuccess(api, devName string) { add(e.successes, api, devName, "") }
+
+// IncAPIError 平台返回业务错误
+func (e *Expvar) IncAPIError(api, devName, code string) {
+	add(e.apiErrors, api, devName, ":"+code)
+}
+
+// IncTransportError 传输错误
+func (e *Expvar) IncTransportError(api, devName string) {
+	add(e.transportErrors, api, devName, "")
+}
+
+// IncRejected 被本地拒绝
+func (e *Expvar) IncRejected(api, devName, reason string) {
+	add(e.rejected, api, devName, ":"+reason)
+}
+
+// IncRetry 重试
+func (e *Expvar) IncRetry(api, devName string) { add(e.retries, api, devName, "") }
+
+// ObserveLatency 请求耗时
+func (e *Expvar) ObserveLatency(api, devName string, d time.Duration) {
+	for _, k := range keys(api, devName) {
+		e.mu.Lock()
+		h, ok := e.latencies[k]
+		if !ok {
+			h = NewHistogram(e.buckets)
+			e.latencies[k] = h
+		}
+		e.mu.Unlock()
+		h.Observe(d)
+	}
+}
+
+// latencySnapshot 导出耗时直方图
+func (e *Expvar) latencySnapshot() interface{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	m := make(map[string]Snapshot, len(e.latencies))
+	for k, h := range e.latencies {
+		m[k] = h.Snapshot()
+	}
+	return m
+}
@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// Labels 指标维度
+type Labels struct {
+	Api     string
+	DevName string
+}
+
+// Counters 单一维度下的计数
+type Counters struct {
+	Requests        int64
+	Successes       int64
+	APIErrors       map[string]int64 // 按错误码统计
+	TransportErrors int64
+	Rejected        map[string]int64 // 按拒绝原因统计
+	Retries         int64
+}
+
+// Memory 内存指标实现，用于测试或自行导出
+type Memory struct {
+	mu        sync.Mutex
+	buckets   []time.Duration
+	counters  map[Labels]*Counters
+	latencies map[Labels]*Histogram
+}
+
+// NewMemory 创建内存指标，buckets 为空时使用 DefaultBuckets
+func NewMemory(buckets ...time.Duration) *Memory {
+	return &Memory{
+		buckets:   buckets,
+		counters:  make(map[Labels]*Counters),
+		latencies: make(map[Labels]*Histogram),
+	}
+}
+
+// counter 获取指定维度的计数，调用方需持有锁
+func (m *Memory) counter(api, devName string) *Counters {
+	l := Labels{Api: api, DevName: devName}
+	c, ok := m.counters[l]
+	if !ok {
+		c = &Counters{APIErrors: make(map[string]int64), Rejected: make(map[string]int64)}
+		m.counters[l] = c
+	}
+	return c
+}
+
+// IncRequest 发起请求
+func (m *Memory) IncRequest(api, devName string) {
+	m.mu.Lock()
+	m.counter(api, devName).Requests++
+	m.mu.Unlock()
+}
+
+// IncSuccess 请求成功
+func (m *Memory) IncSuccess(api, devName string) {
+	m.mu.Lock()
+	m.counter(api, devName).Successes++
+	m.mu.Unlock()
+}
+
+// IncAPIError 平台返回业务错误
+func (m *Memory) IncAPIError(api, devName, code string) {
+	m.mu.Lock()
+	m.counter(api, devName).APIErrors[code]++
+	m.mu.Unlock()
+}
+
+// IncTransportError 传输错误
+func (m *Memory) IncTransportError(api, devName string) {
+	m.mu.Lock()
+	m.counter(api, devName).TransportErrors++
+	m.mu.Unlock()
+}
+
+// IncRejected 被本地拒绝
+func (m *Memory) IncRejected(api, devName, reason string) {
+	m.mu.Lock()
+	m.counter(api, devName).Rejected[reason]++
+	m.mu.Unlock()
+}
+
+// IncRetry 重试
+func (m *Memory) IncRetry(api, devName string) {
+	m.mu.Lock()
+	m.counter(api, devName).Retries++
+	m.mu.Unlock()
+}
+
+// ObserveLatency 请求耗时
+func (m *Memory) ObserveLatency(api, devName string, d time.Duration) {
+	l := Labels{Api: api, DevName: devName}
+	m.mu.Lock()
+	h, ok := m.latencies[l]
+	if !ok {
+		h = NewHistogram(m.buckets)
+		m.latencies[l] = h
+	}
+	m.mu.Unlock()
+	h.Observe(d)
+}
+
+// Counters 获取指定接口及设备的计数快照
+func (m *Memory) Counters(api, devName string) Counters {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	src := m.counter(api, devName)
+	c := *src
+	c.APIErrors = make(map[string]int64, len(src.APIErrors))
+	for k, v := range src.APIErrors {
+		c.APIErrors[k] = v
+	}
+	c.Rejected = make(map[string]int64, len(src.Rejected))
+	for k, v := range src.Rejected {
+		c.Rejected[k] = v
+	}
+	return c
+}
+
+// Latency 获取指定接口及设备的耗时快照
+func (m *Memory) Latency(api, devName string) Snapshot {
+	m.mu.Lock()
+	h, ok := m.latencies[Labels{Api: api, DevName: devName}]
+	m.mu.Unlock()
+	if !ok {
+		return NewHistogram(m.buckets).Snapshot()
+	}
+	return h.Snapshot()
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Metrics 请求监控指标接口，api 为接口名称，devName 为设备名称（可能为空）
+type Metrics interface {
+	IncRequest(api, devName string)                      // 发起请求
+	IncSuccess(api, devName string)                      // 请求成功
+	IncAPIError(api, devName, code string)               // 平台返回业务错误
+	IncTransportError(api, devName string)               // 传输错误
+	IncRejected(api, devName, reason string)             // 被去重、策略、熔断或限流在本地拒绝
+	IncRetry(api, devName string)                        // 重试
+	ObserveLatency(api, devName string, d time.Duration) // 请求耗时
+}
+
+// DefaultBuckets 默认耗时直方图分桶上限
+var DefaultBuckets = []time.Duration{
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Histogram 耗时直方图
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []time.Duration
+	counts  []int64 // 最后一个为超出所有分桶的数量
+	count   int64
+	sum     time.Duration
+}
+
+// NewHistogram 创建直方图，buckets 为空时使用 DefaultBuckets
+func NewHistogram(buckets []time.Duration) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	b := append([]time.Duration(nil), buckets...)
+	sort.Slice(b, func(i, j int) bool { return b[i] < b[j] })
+	return &Histogram{buckets: b, counts: make([]int64, len(b)+1)}
+}
+
+// Observe 记录一次耗时
+func (h *Histogram) Observe(d time.Duration) {
+	i := sort.Search(len(h.buckets), func(i int) bool { return d <= h.buckets[i] })
+	h.mu.Lock()
+	h.counts[i]++
+	h.count++
+	h.sum += d
+	h.mu.Unlock()
+}
+
+// Snapshot 直方图快照
+type Snapshot struct {
+	Buckets []time.Duration `json:"buckets"` // 分桶上限
+	Counts  []int64         `json:"counts"`  // 各分桶数量，最后一个为超出所有分桶的数量
+	Count   int64           `json:"count"`   // 总数
+	Sum     time.Duration   `json:"sum"`     // 总耗时
+}
+
+// Snapshot 获取直方图快照
+func (h *Histogram) Snapshot() Snapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return Snapshot{
+		Buckets: append([]time.Duration(nil), h.buckets...),
+		Counts:  append([]int64(nil), h.counts...),
+		Count:   h.count,
+		Sum:     h.sum,
+	}
+}
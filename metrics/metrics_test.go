@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+)
+
+func TestMemory(t *testing.T) {
+	m := NewMemory()
+	m.IncRequest("play", "bsj00575")
+	m.IncAPIError("play", "bsj00575", "1001")
+	m.IncRetry("play", "bsj00575")
+	m.ObserveLatency("play", "bsj00575", 80*time.Millisecond)
+	m.ObserveLatency("play", "bsj00575", time.Minute)
+	c := m.Counters("play", "bsj00575")
+	if c.Requests != 1 || c.APIErrors["1001"] != 1 || c.Retries != 1 {
+		t.Fatalf("unexpected counters: %+v", c)
+	}
+	s := m.Latency("play", "bsj00575")
+	if s.Count != 2 || s.Counts[1] != 1 || s.Counts[len(s.Counts)-1] != 1 {
+		t.Fatalf("unexpected latency: %+v", s)
+	}
+}
+
+func TestExpvar(t *testing.T) {
+	e := NewExpvar("yxyiot_test")
+	e.IncTransportError("play", "bsj00575")
+	e.ObserveLatency("play", "", time.Second)
+	var v map[string]map[string]interface{}
+	if err := json.Unmarshal([]byte(expvar.Get("yxyiot_test").String()), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v["transport_errors"]["dev:bsj00575"] != float64(1) || v["latency"]["api:play"] == nil {
+		t.Fatalf("unexpected expvar: %v", v)
+	}
+}
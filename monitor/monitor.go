@@ -0,0 +1,249 @@
+package monitor
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bigrocs/yxyiot/responses"
+)
+
+// 事件类型
+const (
+	EventOffline       = "offline"        // 设备离线
+	EventOnline        = "online"         // 设备恢复在线
+	EventPaperOut      = "paper_out"      // 打印机缺纸
+	EventPaperRestored = "paper_restored" // 打印机已装纸
+	EventCoverOpen     = "cover_open"     // 打印机开盖
+	EventCoverClosed   = "cover_closed"   // 打印机已合盖
+)
+
+// DefaultInterval 默认轮询间隔
+const DefaultInterval = time.Minute
+
+// Querier 设备状态查询方，平台状态查询接口尚待文档确认，SDK 暂不提供实现，
+// 可基于已确认的接口或设备状态推送通知实现
+type Querier interface {
+	DeviceStatus(ctx context.Context, devName string) (*responses.DeviceStatus, error)
+}
+
+// Event 设备状态变化事件
+type Event struct {
+	Type     string                  `json:"type"`     // 事件类型
+	DevName  string                  `json:"devName"`  // 设备名称
+	Time     time.Time               `json:"time"`     // 检测到变化的时间
+	Status   *responses.DeviceStatus `json:"status"`   // 当前状态
+	Previous *responses.DeviceStatus `json:"previous"` // 上一次状态，首次检测时为空
+}
+
+// Options 监控配置
+type Options struct {
+	Interval    time.Duration                   // 轮询间隔，默认 DefaultInterval
+	Concurrency int                             // 同时查询的设备数，默认 4
+	Timeout     time.Duration                   // 单次查询超时，默认 10 秒
+	Sinks       []Sink                          // 事件输出
+	OnError     func(devName string, err error) // 查询或事件输出失败回调
+}
+
+// Monitor 定时查询设备状态，检测到离线、缺纸、开盖等变化时输出事件
+// 首次查询以在线、有纸、合盖为基准，设备一开始即异常时同样输出事件
+type Monitor struct {
+	querier Querier
+	opts    Options
+
+	poll    sync.Mutex // 保证同一时间只有一轮查询
+	mu      sync.Mutex
+	devices []string
+	last    map[string]*responses.DeviceStatus
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// New 创建监控并立即开始轮询
+func New(querier Querier, devices []string, opts Options) *Monitor {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Monitor{
+		querier: querier,
+		opts:    opts,
+		devices: append([]string(nil), devices...),
+		last:    make(map[string]*responses.DeviceStatus),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	go m.run(ctx)
+	return m
+}
+
+// SetDevices 替换监控的设备列表，移除设备的历史状态一并清除
+func (m *Monitor) SetDevices(devices []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.devices = append([]string(nil), devices...)
+	keep := make(map[string]bool, len(devices))
+	for _, d := range devices {
+		keep[d] = true
+	}
+	for d := range m.last {
+		if !keep[d] {
+			delete(m.last, d)
+		}
+	}
+}
+
+// Status 返回设备最近一次查询到的状态
+func (m *Monitor) Status(devName string) (*responses.DeviceStatus, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.last[devName]
+	return s, ok
+}
+
+// Close 停止轮询
+func (m *Monitor) Close() {
+	m.cancel()
+	<-m.done
+}
+
+// run 轮询循环
+func (m *Monitor) run(ctx context.Context) {
+	defer close(m.done)
+	ticker := time.NewTicker(m.opts.Interval)
+	defer ticker.Stop()
+	for {
+		m.Poll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll 立即查询一轮设备状态并输出变化事件，与定时轮询互斥
+func (m *Monitor) Poll(ctx context.Context) {
+	m.poll.Lock()
+	defer m.poll.Unlock()
+	m.mu.Lock()
+	devices := m.devices
+	m.mu.Unlock()
+	sem := make(chan struct{}, m.opts.Concurrency)
+	var wg sync.WaitGroup
+	for _, devName := range devices {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		}
+		wg.Add(1)
+		go func(devName string) {
+			defer func() { <-sem; wg.Done() }()
+			m.check(ctx, devName)
+		}(devName)
+	}
+	wg.Wait()
+}
+
+// check 查询单台设备并与上一次状态比较
+func (m *Monitor) check(ctx context.Context, devName string) {
+	qctx, cancel := context.WithTimeout(ctx, m.opts.Timeout)
+	status, err := m.querier.DeviceStatus(qctx, devName)
+	cancel()
+	if err != nil {
+		if ctx.Err() == nil {
+			m.onError(devName, err)
+		}
+		return
+	}
+	m.mu.Lock()
+	if !m.watching(devName) {
+		// 查询期间设备已被 SetDevices 移除
+		m.mu.Unlock()
+		return
+	}
+	prev := m.last[devName]
+	m.last[devName] = status
+	m.mu.Unlock()
+	now := time.Now()
+	for _, typ := range transitions(prev, status) {
+		event := Event{Type: typ, DevName: devName, Time: now, Status: status, Previous: prev}
+		for _, sink := range m.opts.Sinks {
+			if err := sink.Emit(ctx, event); err != nil {
+				m.onError(devName, err)
+			}
+		}
+	}
+}
+
+// watching 设备是否仍在监控列表中，调用方需持有 m.mu
+func (m *Monitor) watching(devName string) bool {
+	for _, d := range m.devices {
+		if d == devName {
+			return true
+		}
+	}
+	return false
+}
+
+// condition 设备的在线、缺纸、开盖状况，合并可识别的打印机状态
+type condition struct {
+	online, paperOut, coverOpen bool
+}
+
+// conditionOf 合并平台返回的状况与 Printer 中可识别的打印机状态，s 为空时以在线、有纸、合盖为基准
+func conditionOf(s *responses.DeviceStatus) condition {
+	if s == nil {
+		return condition{online: true}
+	}
+	c := condition{online: s.Online, paperOut: s.PaperOut, coverOpen: s.CoverOpen}
+	if p := s.Printer; p != nil && p.Known {
+		c.online = c.online && p.Online
+		c.paperOut = c.paperOut || p.PaperOut
+		c.coverOpen = c.coverOpen || p.CoverOpen
+	}
+	return c
+}
+
+// transitions 比较前后两次状态，返回发生的事件类型
+func transitions(prev, cur *responses.DeviceStatus) []string {
+	base, now := conditionOf(prev), conditionOf(cur)
+	var events []string
+	if base.online != now.online {
+		if now.online {
+			events = append(events, EventOnline)
+		} else {
+			events = append(events, EventOffline)
+		}
+	}
+	if base.paperOut != now.paperOut {
+		if now.paperOut {
+			events = append(events, EventPaperOut)
+		} else {
+			events = append(events, EventPaperRestored)
+		}
+	}
+	if base.coverOpen != now.coverOpen {
+		if now.coverOpen {
+			events = append(events, EventCoverOpen)
+		} else {
+			events = append(events, EventCoverClosed)
+		}
+	}
+	return events
+}
+
+// onError 回调错误
+func (m *Monitor) onError(devName string, err error) {
+	if m.opts.OnError != nil {
+		m.opts.OnError(devName, err)
+	}
+}
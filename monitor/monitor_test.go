@@ -0,0 +1,134 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bigrocs/yxyiot/responses"
+)
+
+// fakeQuerier 按轮次返回预设状态
+type fakeQuerier struct {
+	mu     sync.Mutex
+	rounds map[string][]*responses.DeviceStatus
+}
+
+func (q *fakeQuerier) DeviceStatus(ctx context.Context, devName string) (*responses.DeviceStatus, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	list := q.rounds[devName]
+	if len(list) == 0 {
+		return nil, errors.New("connection refused")
+	}
+	q.rounds[devName] = list[1:]
+	return list[0], nil
+}
+
+func TestMonitorTransitions(t *testing.T) {
+	q := &fakeQuerier{rounds: map[string][]*responses.DeviceStatus{
+		"bsj00575": {{Online: true}, {Online: false}, {Online: true}},
+		"bsj00576": {{Online: true, PaperOut: true}, {Online: true, PaperOut: true, CoverOpen: true}, {Online: true}},
+	}}
+	events := make(chan Event, 16)
+	var errs int32
+	m := New(q, []string{"bsj00575", "bsj00576"}, Options{
+		Interval: 10 * time.Millisecond,
+		Sinks:    []Sink{ChannelSink(events)},
+		OnError:  func(string, error) { atomic.AddInt32(&errs, 1) },
+	})
+	got := make(map[string][]string)
+	for i := 0; i < 6; i++ {
+		select {
+		case e := <-events:
+			got[e.DevName] = append(got[e.DevName], e.Type)
+		case <-time.After(time.Second):
+			t.Fatalf("missing events: %v", got)
+		}
+	}
+	// 预设状态用完后查询失败，应通过 OnError 报告
+	for deadline := time.Now().Add(time.Second); atomic.LoadInt32(&errs) == 0 && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+	}
+	m.Close()
+	want := map[string][]string{
+		"bsj00575": {EventOffline, EventOnline},
+		"bsj00576": {EventPaperOut, EventCoverOpen, EventPaperRestored, EventCoverClosed},
+	}
+	for dev, types := range want {
+		if len(got[dev]) != len(types) {
+			t.Fatalf("%s events = %v, want %v", dev, got[dev], types)
+		}
+		for i := range types {
+			if got[dev][i] != types[i] {
+				t.Fatalf("%s events = %v, want %v", dev, got[dev], types)
+			}
+		}
+	}
+	if s, ok := m.Status("bsj00576"); !ok || s.PaperOut || s.CoverOpen {
+		t.Fatalf("unexpected last status: %+v", s)
+	}
+	if atomic.LoadInt32(&errs) == 0 {
+		t.Fatal("query errors not reported")
+	}
+}
+
+func TestMonitorPrinterStatus(t *testing.T) {
+	old := responses.PrinterStatusBits
+	responses.PrinterStatusBits = map[int]responses.PrinterCondition{1: responses.PrinterPaperOut, 2: responses.PrinterCoverOpen}
+	defer func() { responses.PrinterStatusBits = old }()
+	var rounds []*responses.DeviceStatus
+	for _, code := range []string{"1", "3", "0"} {
+		s, err := responses.ParseDeviceStatus(map[string]interface{}{"online": true, "printerStatus": code})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rounds = append(rounds, s)
+	}
+	q := &fakeQuerier{rounds: map[string][]*responses.DeviceStatus{"bsj00576": rounds}}
+	events := make(chan Event, 16)
+	m := New(q, []string{"bsj00576"}, Options{Interval: 10 * time.Millisecond, Sinks: []Sink{ChannelSink(events)}})
+	defer m.Close()
+	want := []string{EventPaperOut, EventCoverOpen, EventPaperRestored, EventCoverClosed}
+	for i := range want {
+		select {
+		case e := <-events:
+			if e.Type != want[i] {
+				t.Fatalf("event %d = %s, want %s", i, e.Type, want[i])
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("missing event %s", want[i])
+		}
+	}
+}
+
+// blockingQuerier 查询阻塞至 release 关闭
+type blockingQuerier struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (q *blockingQuerier) DeviceStatus(ctx context.Context, devName string) (*responses.DeviceStatus, error) {
+	close(q.started)
+	<-q.release
+	return &responses.DeviceStatus{Online: false}, nil
+}
+
+func TestMonitorDeviceRemovedDuringQuery(t *testing.T) {
+	q := &blockingQuerier{started: make(chan struct{}), release: make(chan struct{})}
+	events := make(chan Event, 16)
+	m := New(q, []string{"bsj00575"}, Options{Interval: time.Hour, Sinks: []Sink{ChannelSink(events)}})
+	<-q.started
+	m.SetDevices(nil)
+	close(q.release)
+	m.Close()
+	if len(events) != 0 {
+		t.Fatalf("unexpected event for removed device: %+v", <-events)
+	}
+	if s, ok := m.Status("bsj00575"); ok {
+		t.Fatalf("removed device status kept: %+v", s)
+	}
+}
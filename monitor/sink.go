@@ -0,0 +1,74 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// Sink 事件输出
+type Sink interface {
+	Emit(ctx context.Context, event Event) error
+}
+
+// SinkFunc 以回调函数实现事件输出
+type SinkFunc func(ctx context.Context, event Event) error
+
+// Emit 输出事件
+func (f SinkFunc) Emit(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// ChannelSink 将事件写入通道，通道已满时阻塞直至上下文取消
+type ChannelSink chan<- Event
+
+// Emit 输出事件
+func (c ChannelSink) Emit(ctx context.Context, event Event) error {
+	select {
+	case c <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WebhookSink 以 JSON 格式 POST 事件到指定地址
+type WebhookSink struct {
+	URL    string
+	Header http.Header  // 附加请求头，如鉴权信息
+	Client *http.Client // 为空时使用 http.DefaultClient
+}
+
+// Emit 输出事件，返回非 2xx 状态码时视为失败
+func (w *WebhookSink) Emit(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range w.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("monitor: webhook %s returned %s", w.URL, resp.Status)
+	}
+	return nil
+}
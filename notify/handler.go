@@ -0,0 +1,211 @@
+package notify
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bigrocs/yxyiot/dedupe"
+	"github.com/bigrocs/yxyiot/responses"
+)
+
+// DefaultMaxSkew 通知时间与本地时间允许的最大偏差，超出视为重放
+const DefaultMaxSkew = 5 * time.Minute
+
+// Handler 接收平台推送的通知：校验签名、拒绝重放后分发到已注册的处理函数
+// 处理函数返回错误时响应 500，平台重试的同一通知会再次分发；重复的通知直接响应成功，不再分发
+type Handler struct {
+	AppId      string        // 应用ID，不为空时校验通知中的 appId
+	AppSecret  string        // 应用密钥，为空时拒绝全部通知
+	SignedKeys []string      // 参与签名的参数名，为空时除 token 外全部参与；requestId、timestamp 始终参与
+	MaxSkew    time.Duration // 允许的时间偏差，默认 DefaultMaxSkew
+	Replays    dedupe.Store  // 已处理通知的 requestId，为空时首次处理通知前创建 dedupe.MemoryStore
+	OnError    func(r *http.Request, err error)
+
+	replaysOnce sync.Once
+
+	printResult []func(ctx context.Context, event *PrintResultEvent) error
+	deviceState []func(ctx context.Context, event *DeviceStateEvent) error
+	other       []func(ctx context.Context, n *Notification) error
+}
+
+// NewHandler 创建通知处理器
+func NewHandler(appId, appSecret string) *Handler {
+	return &Handler{
+		AppId:     appId,
+		AppSecret: appSecret,
+		Replays:   dedupe.NewMemoryStore(),
+	}
+}
+
+// HandlePrintResult 注册打印结果处理函数，应在开始接收通知前调用
+func (h *Handler) HandlePrintResult(fn func(ctx context.Context, event *PrintResultEvent) error) {
+	h.printResult = append(h.printResult, fn)
+}
+
+// HandleDeviceState 注册设备状态变化处理函数，应在开始接收通知前调用
+func (h *Handler) HandleDeviceState(fn func(ctx context.Context, event *DeviceStateEvent) error) {
+	h.deviceState = append(h.deviceState, fn)
+}
+
+// HandleOther 注册其他类型通知的处理函数，应在开始接收通知前调用
+func (h *Handler) HandleOther(fn func(ctx context.Context, n *Notification) error) {
+	h.other = append(h.other, fn)
+}
+
+// ServeHTTP 处理通知请求
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		h.reply(w, r, http.StatusMethodNotAllowed, errors.New("notify: method not allowed"))
+		return
+	}
+	n, err := Parse(r)
+	if err != nil {
+		h.reply(w, r, http.StatusBadRequest, err)
+		return
+	}
+	if err = h.Verify(n, time.Now()); err != nil {
+		h.reply(w, r, http.StatusForbidden, err)
+		return
+	}
+	key := "notify:" + n.RequestId
+	replays := h.replays()
+	ok, err := replays.SetIfAbsent(key, 2*h.maxSkew())
+	if err != nil {
+		h.reply(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if !ok {
+		h.reply(w, r, http.StatusOK, ErrDuplicate)
+		return
+	}
+	if err = h.dispatch(r.Context(), n); err != nil {
+		replays.Delete(key)
+		h.reply(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	h.reply(w, r, http.StatusOK, nil)
+}
+
+// Verify 校验通知的应用ID、签名及时间戳，未配置 AppSecret 时返回 ErrNoSecret
+// 用于防重放的 requestId、timestamp 必须存在并参与签名，否则无法阻止篡改后的重放
+func (h *Handler) Verify(n *Notification, now time.Time) error {
+	if h.AppSecret == "" {
+		return ErrNoSecret
+	}
+	if h.AppId != "" && n.AppId != h.AppId {
+		return ErrInvalidToken
+	}
+	if n.RequestId == "" || n.Timestamp.IsZero() {
+		return ErrStale
+	}
+	token, _ := n.Params["token"].(string)
+	expected := Sign(n.Params, h.AppSecret, h.signedKeys()...)
+	if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+		return ErrInvalidToken
+	}
+	if d := now.Sub(n.Timestamp); d > h.maxSkew() || d < -h.maxSkew() {
+		return ErrStale
+	}
+	return nil
+}
+
+// dispatch 按通知类型分发
+func (h *Handler) dispatch(ctx context.Context, n *Notification) error {
+	switch n.Type {
+	case TypePrintResult:
+		status, err := responses.ParsePrintJobStatus(n.Params)
+		if err != nil {
+			return err
+		}
+		event := &PrintResultEvent{Notification: n, Status: status}
+		for _, fn := range h.printResult {
+			if err := fn(ctx, event); err != nil {
+				return err
+			}
+		}
+	case TypeDeviceState:
+		status, err := responses.ParseDeviceStatus(n.Params)
+		if err != nil {
+			return err
+		}
+		if status.DevName == "" {
+			status.DevName = n.DevName
+		}
+		event := &DeviceStateEvent{Notification: n, Status: status}
+		for _, fn := range h.deviceState {
+			if err := fn(ctx, event); err != nil {
+				return err
+			}
+		}
+	default:
+		for _, fn := range h.other {
+			if err := fn(ctx, n); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// replays 已处理通知的存储，未配置时创建内存存储，保证重放校验不会被跳过
+func (h *Handler) replays() dedupe.Store {
+	h.replaysOnce.Do(func() {
+		if h.Replays == nil {
+			h.Replays = dedupe.NewMemoryStore()
+		}
+	})
+	return h.Replays
+}
+
+// signedKeys 参与签名的参数名，配置了 SignedKeys 时补充 requestId 与 timestamp
+func (h *Handler) signedKeys() []string {
+	if len(h.SignedKeys) == 0 {
+		return nil
+	}
+	keys := append([]string(nil), h.SignedKeys...)
+	for _, k := range []string{"requestId", "timestamp"} {
+		if !contains(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// contains 判断切片中是否包含指定字符串
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// maxSkew 允许的时间偏差
+func (h *Handler) maxSkew() time.Duration {
+	if h.MaxSkew > 0 {
+		return h.MaxSkew
+	}
+	return DefaultMaxSkew
+}
+
+// reply 以平台相同的格式响应
+func (h *Handler) reply(w http.ResponseWriter, r *http.Request, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err == nil || err == ErrDuplicate {
+		w.Write([]byte(`{"code":0,"msg":"success"}`))
+		return
+	}
+	if h.OnError != nil {
+		h.OnError(r, err)
+	}
+	// 不在响应中暴露内部错误细节
+	w.Write([]byte(`{"code":` + strconv.Itoa(status) + `,"msg":"` + http.StatusText(status) + `"}`))
+}
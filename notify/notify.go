@@ -0,0 +1,128 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bigrocs/yxyiot/responses"
+	"github.com/bigrocs/yxyiot/util"
+)
+
+// 通知类型，即通知 type 参数的取值，需与平台推送配置一致
+const (
+	TypePrintResult = "printResult" // 打印结果
+	TypeDeviceState = "deviceState" // 设备状态变化
+)
+
+// TimestampUnit 通知 timestamp 参数的单位，默认与请求签名（common.Common）所用的毫秒时间戳一致，
+// 平台推送使用其他单位时修改
+var TimestampUnit = time.Millisecond
+
+// 通知校验错误
+var (
+	ErrInvalidToken = errors.New("notify: invalid token")
+	ErrNoSecret     = errors.New("notify: app secret not configured")
+	ErrStale        = errors.New("notify: timestamp out of range")
+	ErrDuplicate    = errors.New("notify: duplicate notification")
+)
+
+// maxBodySize JSON 通知内容最大字节数
+const maxBodySize = 1 << 20
+
+// Notification 平台推送的通知，Params 中的取值均为字符串
+type Notification struct {
+	Type      string                 `json:"type"`      // 通知类型，见 TypePrintResult 等
+	AppId     string                 `json:"appId"`     // 应用ID
+	RequestId string                 `json:"requestId"` // 通知的 requestId
+	DevName   string                 `json:"devName"`   // 设备名称
+	Timestamp time.Time              `json:"timestamp"` // 通知发送时间
+	Params    map[string]interface{} `json:"params"`    // 全部通知参数
+}
+
+// PrintResultEvent 打印结果通知
+type PrintResultEvent struct {
+	*Notification
+	Status *responses.PrintJobStatus
+}
+
+// DeviceStateEvent 设备状态变化通知，打印机状态见 Status.Printer
+type DeviceStateEvent struct {
+	*Notification
+	Status *responses.DeviceStatus
+}
+
+// Parse 解析通知内容，支持 JSON 及表单格式
+// JSON 通知的取值须均为字符串（或 null），以保证签名原文与表单一致；含数字、布尔值或对象时返回错误
+func Parse(r *http.Request) (*Notification, error) {
+	params := make(map[string]interface{})
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBodySize))
+		if err != nil {
+			return nil, err
+		}
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(body, &raw); err != nil {
+			return nil, fmt.Errorf("notify: invalid json: %v", err)
+		}
+		for k, v := range raw {
+			if string(bytes.TrimSpace(v)) == "null" {
+				continue
+			}
+			var str string
+			if err := json.Unmarshal(v, &str); err != nil {
+				return nil, fmt.Errorf("notify: json field %s is not a string", k)
+			}
+			params[k] = str
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			return nil, err
+		}
+		for k, v := range r.Form {
+			if len(v) > 0 {
+				params[k] = v[0]
+			}
+		}
+	}
+	n := &Notification{Params: params}
+	n.Type, _ = params["type"].(string)
+	n.AppId, _ = params["appId"].(string)
+	n.RequestId, _ = params["requestId"].(string)
+	n.DevName, _ = params["devName"].(string)
+	if ts, ok := params["timestamp"].(string); ok {
+		if v, err := strconv.ParseInt(ts, 10, 64); err == nil {
+			n.Timestamp = time.Unix(0, v*int64(TimestampUnit))
+		}
+	}
+	return n, nil
+}
+
+// Sign 计算通知签名：除 token 外的参数按名称排序拼接后追加 appSecret，取 MD5 大写
+// 与请求签名使用相同的 util.FormatParam 规则；keys 不为空时仅对指定参数签名
+func Sign(params map[string]interface{}, appSecret string, keys ...string) string {
+	signed := make(map[string]interface{}, len(params))
+	if len(keys) > 0 {
+		for _, k := range keys {
+			if v, ok := params[k]; ok {
+				signed[k] = v
+			}
+		}
+	} else {
+		for k, v := range params {
+			if k != "token" {
+				signed[k] = v
+			}
+		}
+	}
+	return strings.ToUpper(util.Md5([]byte(util.FormatParam(signed, appSecret))))
+}
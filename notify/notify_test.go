@@ -0,0 +1,139 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bigrocs/yxyiot/responses"
+)
+
+func TestHandler(t *testing.T) {
+	defer func(codes map[string]string) { responses.PrintStateCodes = codes }(responses.PrintStateCodes)
+	responses.PrintStateCodes = map[string]string{"1": responses.PrintStatePrinted}
+	h := NewHandler("app001", "secret")
+	var printed []*PrintResultEvent
+	var states []*DeviceStateEvent
+	h.HandlePrintResult(func(ctx context.Context, e *PrintResultEvent) error {
+		printed = append(printed, e)
+		return nil
+	})
+	h.HandleDeviceState(func(ctx context.Context, e *DeviceStateEvent) error {
+		states = append(states, e)
+		return nil
+	})
+	now := strconv.FormatInt(time.Now().UnixNano()/1e6, 10)
+
+	// JSON 格式的打印结果
+	params := map[string]interface{}{"type": "printResult", "appId": "app001", "requestId": "r-1", "devName": "bsj00576", "printStatus": "1", "timestamp": now}
+	params["token"] = Sign(params, "secret")
+	b, _ := json.Marshal(params)
+	body := string(b)
+	for i, want := range []int{http.StatusOK, http.StatusOK} {
+		r := httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json; charset=utf-8")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != want {
+			t.Fatalf("request %d: status %d %s", i, w.Code, w.Body)
+		}
+	}
+	if len(printed) != 1 || printed[0].Status.State != responses.PrintStatePrinted {
+		t.Fatalf("replayed notification dispatched or wrong state: %+v", printed)
+	}
+
+	// 表单格式的设备状态
+	form := url.Values{"type": {"deviceState"}, "appId": {"app001"}, "requestId": {"r-2"}, "devName": {"bsj00576"}, "online": {"1"}, "printerStatus": {"5"}, "timestamp": {now}}
+	signed := make(map[string]interface{})
+	for k, v := range form {
+		signed[k] = v[0]
+	}
+	form.Set("token", Sign(signed, "secret"))
+	r := httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || len(states) != 1 {
+		t.Fatalf("status %d %s", w.Code, w.Body)
+	}
+	if s := states[0].Status; !s.Online || s.PaperOut || s.Printer.Code != "5" || s.Printer.Known {
+		t.Fatalf("unexpected device state: %+v", s)
+	}
+
+	// 签名错误及过期通知
+	stale := map[string]interface{}{"type": "printResult", "appId": "app001", "requestId": "r-3", "timestamp": "1600000000000"}
+	stale["token"] = Sign(stale, "secret")
+	forged := map[string]interface{}{"type": "printResult", "appId": "app001", "requestId": "r-4", "timestamp": now, "token": "FORGED"}
+	for _, p := range []map[string]interface{}{stale, forged} {
+		b, _ := json.Marshal(p)
+		r := httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(string(b)))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("expected forbidden for %v, got %d", p, w.Code)
+		}
+	}
+}
+
+func TestHandlerRejectsUnsignedReplayFields(t *testing.T) {
+	h := NewHandler("app001", "secret")
+	h.SignedKeys = []string{"appId", "devName"}
+	now := strconv.FormatInt(time.Now().UnixNano()/1e6, 10)
+	params := map[string]interface{}{"type": "printResult", "appId": "app001", "requestId": "r-1", "devName": "bsj00576", "printStatus": "1", "timestamp": now}
+	params["token"] = Sign(params, "secret", "appId", "devName", "requestId", "timestamp")
+	n := &Notification{AppId: "app001", RequestId: "r-1", Timestamp: time.Now(), Params: params}
+	if err := h.Verify(n, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	// 篡改 requestId 以绕过重放检测
+	n.Params["requestId"], n.RequestId = "r-2", "r-2"
+	if err := h.Verify(n, time.Now()); err != ErrInvalidToken {
+		t.Fatalf("expected invalid token, got %v", err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(`{"type":"printResult","printStatus":1}`))
+	r.Header.Set("Content-Type", "application/json")
+	if _, err := Parse(r); err == nil {
+		t.Fatal("expected error for non-string json value")
+	}
+}
+
+func TestHandlerFailsClosed(t *testing.T) {
+	now := strconv.FormatInt(time.Now().UnixNano()/1e6, 10)
+	params := map[string]interface{}{"type": "printResult", "appId": "app001", "requestId": "r-1", "printStatus": "1", "timestamp": now}
+	params["token"] = Sign(params, "")
+	n := &Notification{AppId: "app001", RequestId: "r-1", Timestamp: time.Now(), Params: params}
+	if err := (&Handler{AppId: "app001"}).Verify(n, time.Now()); err != ErrNoSecret {
+		t.Fatalf("expected missing secret error, got %v", err)
+	}
+
+	// 未通过 NewHandler 创建时仍需拒绝重放
+	h := &Handler{AppId: "app001", AppSecret: "secret"}
+	calls := 0
+	h.HandleOther(func(ctx context.Context, n *Notification) error {
+		calls++
+		return nil
+	})
+	params = map[string]interface{}{"type": "other", "appId": "app001", "requestId": "r-2", "timestamp": now}
+	params["token"] = Sign(params, "secret")
+	b, _ := json.Marshal(params)
+	for i := 0; i < 2; i++ {
+		r := httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(string(b)))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: status %d %s", i, w.Code, w.Body)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("replayed notification dispatched %d times", calls)
+	}
+}
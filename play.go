@@ -0,0 +1,29 @@
+package yxyiot
+
+import (
+	"context"
+
+	"github.com/bigrocs/yxyiot/requests"
+	"github.com/bigrocs/yxyiot/responses"
+)
+
+// Play 发送云播报，设备名称可使用注册表中的别名，发送前校验设备能力；平台返回业务错误时以 *responses.APIError 返回
+func (client *Client) Play(ctx context.Context, request *requests.PlayRequest) (response *responses.CommonResponse, err error) {
+	r := *request
+	if d, ok := client.device(r.DevName); ok {
+		r.DevName = d.Name
+	}
+	return client.process(ctx, r.CommonRequest())
+}
+
+// process 处理请求，并将平台业务错误转换为错误返回
+func (client *Client) process(ctx context.Context, request *requests.CommonRequest) (response *responses.CommonResponse, err error) {
+	response, err = client.ProcessCommonRequestWithContext(ctx, request)
+	if err != nil {
+		return response, err
+	}
+	if e := response.GetAPIError(); e != nil {
+		return response, e
+	}
+	return response, nil
+}
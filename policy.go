@@ -0,0 +1,43 @@
+package yxyiot
+
+import (
+	"time"
+
+	"github.com/bigrocs/yxyiot/policy"
+	"github.com/bigrocs/yxyiot/requests"
+	"github.com/bigrocs/yxyiot/responses"
+	"github.com/bigrocs/yxyiot/util"
+)
+
+// applyPolicy 对请求执行播报策略（静默时段等），返回实际发送的请求
+// 丢弃及推迟分别返回 *policy.DroppedError、*policy.DeferredError，改投打印机时返回打印请求
+func (client *Client) applyPolicy(request *requests.CommonRequest, response *responses.CommonResponse) (*requests.CommonRequest, error) {
+	if client.Policy == nil {
+		return request, nil
+	}
+	devName := util.InterfaceToString(request.BizContent["devName"])
+	d := client.Policy.EvaluateApi(request.ApiName, devName, util.InterfaceToString(request.BizContent["bizType"]), time.Now())
+	switch d.Action {
+	case policy.ActionDrop:
+		return nil, &policy.DroppedError{Rule: d.Rule, Api: request.ApiName, DevName: devName}
+	case policy.ActionDefer:
+		return nil, &policy.DeferredError{Rule: d.Rule, Api: request.ApiName, DevName: devName, Until: d.Until}
+	case policy.ActionReroute:
+		r := requests.NewPrintRequest(d.Printer, playText(request)+"<BR>").CommonRequest()
+		if v, ok := request.BizContent["requestId"]; ok {
+			r.BizContent["requestId"] = v
+		}
+		r.BizKey = request.BizKey
+		response.Request = r
+		return r, nil
+	}
+	return request, nil
+}
+
+// playText 播报请求的文字内容，仅有金额时按 "收款xx元" 生成
+func playText(request *requests.CommonRequest) string {
+	if content := util.InterfaceToString(request.BizContent["content"]); content != "" {
+		return content
+	}
+	return "收款" + util.InterfaceToString(request.BizContent["money"]) + "元"
+}
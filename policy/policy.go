@@ -0,0 +1,232 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+// 策略动作
+const (
+	ActionAllow   = "allow"   // 正常播报
+	ActionDrop    = "drop"    // 丢弃播报
+	ActionDefer   = "defer"   // 推迟到静默时段结束后播报
+	ActionReroute = "reroute" // 改为在打印机上打印
+)
+
+// ApiPlay 规则未指定接口时适用的接口
+const ApiPlay = "play"
+
+// Config 播报策略配置，可由 JSON 或 YAML 文件加载
+type Config struct {
+	Rules []Rule `json:"rules" yaml:"rules"`
+}
+
+// Rule 播报策略规则，按顺序匹配，第一条命中的规则生效
+type Rule struct {
+	Name     string   `json:"name" yaml:"name"`         // 规则名称
+	Devices  []string `json:"devices" yaml:"devices"`   // 适用设备，"*" 表示全部设备
+	Groups   []string `json:"groups" yaml:"groups"`     // 适用设备分组
+	Timezone string   `json:"timezone" yaml:"timezone"` // 时区，如 Asia/Shanghai，默认本地时区
+	Quiet    []Window `json:"quiet" yaml:"quiet"`       // 静默时段
+	BizTypes []string `json:"bizTypes" yaml:"bizTypes"` // 受限的业务类型，为空时限制全部类型
+	Apis     []string `json:"apis" yaml:"apis"`         // 适用接口，如 play、print，默认仅 play
+	Action   string   `json:"action" yaml:"action"`     // 静默时段内的动作：drop、defer、reroute
+	Printer  string   `json:"printer" yaml:"printer"`   // reroute 时使用的打印机设备
+}
+
+// Window 每日时段，格式 "HH:MM"，End 早于 Start 时表示跨越零点
+type Window struct {
+	Start string `json:"start" yaml:"start"`
+	End   string `json:"end" yaml:"end"`
+}
+
+// Decision 策略评估结果
+type Decision struct {
+	Action  string
+	Rule    string    // 命中的规则名称
+	Until   time.Time // defer 时可播报的时间
+	Printer string    // reroute 时的打印机设备
+}
+
+// DroppedError 请求被策略丢弃
+type DroppedError struct {
+	Rule    string
+	Api     string
+	DevName string
+}
+
+func (e *DroppedError) Error() string {
+	return fmt.Sprintf("yxyiot: %s to %s dropped by policy %q", e.Api, e.DevName, e.Rule)
+}
+
+// DeferredError 请求需推迟到 Until 之后
+type DeferredError struct {
+	Rule    string
+	Api     string
+	DevName string
+	Until   time.Time
+}
+
+func (e *DeferredError) Error() string {
+	return fmt.Sprintf("yxyiot: %s to %s deferred by policy %q until %s", e.Api, e.DevName, e.Rule, e.Until.Format(time.RFC3339))
+}
+
+// window 解析后的时段，单位为距零点的分钟数
+type window struct{ start, end int }
+
+// rule 解析后的规则
+type rule struct {
+	Rule
+	loc     *time.Location
+	windows []window
+}
+
+// Engine 播报策略引擎，静默时段统一在此配置
+type Engine struct {
+	rules []rule
+	// Groups 查询设备所属分组，为空时仅按设备名称匹配
+	Groups func(devName string) []string
+}
+
+// New 由配置创建策略引擎
+func New(cfg Config) (*Engine, error) {
+	e := &Engine{}
+	for i, r := range cfg.Rules {
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		switch r.Action {
+		case ActionDrop, ActionDefer:
+		case ActionReroute:
+			if r.Printer == "" {
+				return nil, fmt.Errorf("policy: rule %q reroute requires printer", r.Name)
+			}
+			for _, api := range r.Apis {
+				if api != ApiPlay {
+					return nil, fmt.Errorf("policy: rule %q reroute only applies to %s", r.Name, ApiPlay)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("policy: rule %q has unknown action %q", r.Name, r.Action)
+		}
+		if len(r.Apis) == 0 {
+			r.Apis = []string{ApiPlay}
+		}
+		c := rule{Rule: r, loc: time.Local}
+		if r.Timezone != "" {
+			loc, err := time.LoadLocation(r.Timezone)
+			if err != nil {
+				return nil, fmt.Errorf("policy: rule %q: %v", r.Name, err)
+			}
+			c.loc = loc
+		}
+		for _, w := range r.Quiet {
+			start, err1 := parseClock(w.Start)
+			end, err2 := parseClock(w.End)
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("policy: rule %q has invalid window %s-%s", r.Name, w.Start, w.End)
+			}
+			c.windows = append(c.windows, window{start: start, end: end})
+		}
+		e.rules = append(e.rules, c)
+	}
+	return e, nil
+}
+
+// Load 解析策略配置，unmarshal 为空时按 JSON 解析，可传入 yaml.Unmarshal 解析 YAML
+func Load(data []byte, unmarshal func([]byte, interface{}) error) (*Engine, error) {
+	if unmarshal == nil {
+		unmarshal = json.Unmarshal
+	}
+	var cfg Config
+	if err := unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	return New(cfg)
+}
+
+// LoadFile 从文件加载策略配置
+func LoadFile(path string, unmarshal func([]byte, interface{}) error) (*Engine, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Load(data, unmarshal)
+}
+
+// parseClock 解析 "HH:MM"
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Evaluate 评估设备在 now 时刻播报指定业务类型的动作
+func (e *Engine) Evaluate(devName, bizType string, now time.Time) Decision {
+	return e.EvaluateApi(ApiPlay, devName, bizType, now)
+}
+
+// EvaluateApi 评估设备在 now 时刻调用指定接口的动作，非播报接口的 bizType 可为空
+func (e *Engine) EvaluateApi(api, devName, bizType string, now time.Time) Decision {
+	var groups []string
+	if e.Groups != nil {
+		groups = e.Groups(devName)
+	}
+	for _, r := range e.rules {
+		if !contains(r.Apis, api) || !r.matches(devName, groups, bizType) {
+			continue
+		}
+		if until, ok := r.quietUntil(now); ok {
+			return Decision{Action: r.Action, Rule: r.Name, Until: until, Printer: r.Printer}
+		}
+	}
+	return Decision{Action: ActionAllow}
+}
+
+// matches 判断规则是否适用于设备及业务类型
+func (r *rule) matches(devName string, groups []string, bizType string) bool {
+	if len(r.BizTypes) > 0 && !contains(r.BizTypes, bizType) {
+		return false
+	}
+	if contains(r.Devices, "*") || contains(r.Devices, devName) {
+		return true
+	}
+	for _, g := range groups {
+		if contains(r.Groups, g) {
+			return true
+		}
+	}
+	return false
+}
+
+// quietUntil 判断 now 是否处于静默时段，返回时段结束时间
+func (r *rule) quietUntil(now time.Time) (time.Time, bool) {
+	local := now.In(r.loc)
+	minute := local.Hour()*60 + local.Minute()
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, r.loc)
+	for _, w := range r.windows {
+		switch {
+		case w.start <= w.end && minute >= w.start && minute < w.end:
+			return midnight.Add(time.Duration(w.end) * time.Minute), true
+		case w.start > w.end && minute >= w.start:
+			return midnight.AddDate(0, 0, 1).Add(time.Duration(w.end) * time.Minute), true
+		case w.start > w.end && minute < w.end:
+			return midnight.Add(time.Duration(w.end) * time.Minute), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// contains 判断切片中是否包含指定字符串
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package policy
+
+import (
+	"testing"
+	"time"
+)
+
+const config = `{
+	"rules": [
+		{"name": "residential", "groups": ["residential"], "timezone": "Asia/Shanghai",
+		 "quiet": [{"start": "22:00", "end": "07:30"}], "action": "defer"},
+		{"name": "promo", "devices": ["*"], "bizTypes": ["3"], "timezone": "Asia/Shanghai",
+		 "quiet": [{"start": "12:00", "end": "13:00"}], "action": "reroute", "printer": "bsj00576"}
+	]
+}`
+
+func TestEvaluate(t *testing.T) {
+	e, err := Load([]byte(config), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Groups = func(devName string) []string {
+		if devName == "bsj00575" {
+			return []string{"residential"}
+		}
+		return nil
+	}
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	night := time.Date(2026, 10, 19, 23, 15, 0, 0, loc)
+	d := e.Evaluate("bsj00575", "2", night)
+	if d.Action != ActionDefer || !d.Until.Equal(time.Date(2026, 10, 20, 7, 30, 0, 0, loc)) {
+		t.Fatalf("unexpected decision: %+v", d)
+	}
+	if d := e.Evaluate("bsj00577", "2", night); d.Action != ActionAllow {
+		t.Fatalf("device outside group should be allowed: %+v", d)
+	}
+	noon := time.Date(2026, 10, 19, 12, 10, 0, 0, loc)
+	if d := e.Evaluate("bsj00577", "3", noon); d.Action != ActionReroute || d.Printer != "bsj00576" {
+		t.Fatalf("unexpected decision: %+v", d)
+	}
+	if _, err := Load([]byte(`{"rules":[{"action":"mute"}]}`), nil); err == nil {
+		t.Fatal("expected unknown action error")
+	}
+}
+
+func TestEvaluateApi(t *testing.T) {
+	e, err := Load([]byte(`{"rules":[{"name":"closed","devices":["*"],"apis":["play","print"],
+		"quiet":[{"start":"00:00","end":"23:59"}],"action":"drop"}]}`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+	if d := e.EvaluateApi("print", "bsj00576", "", now); d.Action != ActionDrop || d.Rule != "closed" {
+		t.Fatalf("unexpected decision: %+v", d)
+	}
+	if d := e.EvaluateApi("status", "bsj00576", "", now); d.Action != ActionAllow {
+		t.Fatalf("api outside rule should be allowed: %+v", d)
+	}
+	if _, err := Load([]byte(`{"rules":[{"apis":["print"],"action":"reroute","printer":"bsj00576"}]}`), nil); err == nil {
+		t.Fatal("expected reroute api error")
+	}
+}
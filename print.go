@@ -0,0 +1,41 @@
+package yxyiot
+
+import (
+	"context"
+	"time"
+
+	"github.com/bigrocs/yxyiot/dedupe"
+	"github.com/bigrocs/yxyiot/registry"
+	"github.com/bigrocs/yxyiot/requests"
+	"github.com/bigrocs/yxyiot/responses"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Print 发送云打印，设备名称可使用注册表中的别名，并在发送前校验设备能力；
+// 未指定 RequestId 时预先生成，可由 response.RequestId() 获取并用于关联打印结果通知；平台返回业务错误时以 *responses.APIError 返回
+func (client *Client) Print(ctx context.Context, request *requests.PrintRequest) (response *responses.CommonResponse, err error) {
+	r := *request
+	if d, ok := client.device(r.DevName); ok {
+		r.DevName = d.Name
+		if err = registry.ValidatePrint(d, &r); err != nil {
+			return nil, err
+		}
+	}
+	if r.RequestId == "" { // 预先生成 requestId，以便关联打印任务状态
+		if r.BizKey != "" {
+			r.RequestId = dedupe.RequestId("print", r.BizKey)
+		} else {
+			r.RequestId = uuid.NewV4().String()
+		}
+	}
+	response, err = client.process(ctx, r.CommonRequest())
+	if err == nil && response.Request.ApiName == "print" {
+		client.setPrintState(&responses.PrintJobStatus{
+			RequestId: response.RequestId(),
+			DevName:   r.DevName,
+			State:     responses.PrintStateQueued,
+			UpdatedAt: time.Now(),
+		})
+	}
+	return response, err
+}
@@ -0,0 +1,59 @@
+package yxyiot
+
+import (
+	"sync"
+
+	"github.com/bigrocs/yxyiot/responses"
+)
+
+// PrintStateStore 打印任务状态存储，可供后厨屏幕等展示出票状态
+// Print 成功后记录为已提交，出票结果可由打印结果推送通知（notify.PrintResultEvent）调用 SetPrintState 更新
+type PrintStateStore interface {
+	SetPrintState(status *responses.PrintJobStatus)
+	PrintState(requestId string) (*responses.PrintJobStatus, bool)
+}
+
+// MemoryPrintStates 内存打印任务状态存储，不会自动清理
+type MemoryPrintStates struct {
+	mu     sync.RWMutex
+	states map[string]*responses.PrintJobStatus
+}
+
+// NewMemoryPrintStates 创建内存打印任务状态存储
+func NewMemoryPrintStates() *MemoryPrintStates {
+	return &MemoryPrintStates{states: make(map[string]*responses.PrintJobStatus)}
+}
+
+// SetPrintState 更新任务状态
+func (m *MemoryPrintStates) SetPrintState(status *responses.PrintJobStatus) {
+	s := *status
+	m.mu.Lock()
+	m.states[s.RequestId] = &s
+	m.mu.Unlock()
+}
+
+// PrintState 查询任务状态
+func (m *MemoryPrintStates) PrintState(requestId string) (*responses.PrintJobStatus, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s, ok := m.states[requestId]
+	if !ok {
+		return nil, false
+	}
+	c := *s
+	return &c, true
+}
+
+// Delete 删除任务状态
+func (m *MemoryPrintStates) Delete(requestId string) {
+	m.mu.Lock()
+	delete(m.states, requestId)
+	m.mu.Unlock()
+}
+
+// setPrintState 记录打印任务状态，未配置状态存储时忽略
+func (client *Client) setPrintState(status *responses.PrintJobStatus) {
+	if client.PrintStates != nil && status.RequestId != "" {
+		client.PrintStates.SetPrintState(status)
+	}
+}
@@ -0,0 +1,34 @@
+package yxyiot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bigrocs/yxyiot/requests"
+	"github.com/bigrocs/yxyiot/responses"
+)
+
+func TestPrintStates(t *testing.T) {
+	client := NewClient()
+	client.PrintStates = NewMemoryPrintStates()
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, request *requests.CommonRequest, response *responses.CommonResponse) error {
+			response.SetHttpContent([]byte(`{"code":0}`), "string")
+			return nil
+		}
+	})
+	ticket := requests.NewPrintRequest("bsj00576", "订单编号: 1200897812792015996<BR>")
+	ticket.BizKey = "1200897812792015996"
+	response, err := client.Print(context.Background(), ticket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requestId := response.RequestId()
+	if s, ok := client.PrintStates.PrintState(requestId); !ok || s.State != responses.PrintStateQueued || s.DevName != "bsj00576" {
+		t.Fatalf("unexpected state after print: %+v", s)
+	}
+	client.PrintStates.SetPrintState(&responses.PrintJobStatus{RequestId: requestId, State: responses.PrintStatePrinted})
+	if s, _ := client.PrintStates.PrintState(requestId); !s.Done() {
+		t.Fatalf("state not updated: %+v", s)
+	}
+}
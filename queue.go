@@ -0,0 +1,93 @@
+package yxyiot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bigrocs/yxyiot/requests"
+	"github.com/bigrocs/yxyiot/responses"
+)
+
+// PrintQueue 查询打印机待打印任务，需先按平台文档以 common.RegisterApi 注册 "printQueue" 接口
+func (client *Client) PrintQueue(ctx context.Context, devName string) (*responses.PrintQueue, error) {
+	if d, ok := client.device(devName); ok {
+		devName = d.Name
+	}
+	response, err := client.process(ctx, requests.NewPrintQueueRequest(devName).CommonRequest())
+	if err != nil {
+		return nil, err
+	}
+	queue, err := response.GetPrintQueue()
+	if err != nil {
+		return nil, err
+	}
+	if queue.DevName == "" {
+		queue.DevName = devName
+	}
+	return queue, nil
+}
+
+// ClearPrintQueue 清空打印机全部待打印任务，返回被清除的任务数，平台未返回时为 -1
+// 需先按平台文档以 common.RegisterApi 注册 "clearQueue" 接口
+func (client *Client) ClearPrintQueue(ctx context.Context, devName string) (int, error) {
+	if d, ok := client.device(devName); ok {
+		devName = d.Name
+	}
+	response, err := client.process(ctx, requests.NewClearQueueRequest(devName).CommonRequest())
+	if err != nil {
+		return 0, err
+	}
+	return response.GetClearedCount()
+}
+
+// StaleJobs 查询打印机队列中提交时间早于 maxAge 之前的待打印任务，仅报告不清除
+// 平台未提供按任务删除的接口，打印前自动清空积压队列见 Client.ClearStaleBefore；
+// 平台未返回任务明细时返回 responses.ErrMissingField
+func (client *Client) StaleJobs(ctx context.Context, devName string, maxAge time.Duration) ([]responses.QueuedJob, error) {
+	queue, err := client.PrintQueue(ctx, devName)
+	if err != nil {
+		return nil, err
+	}
+	if queue.Jobs == nil {
+		return nil, fmt.Errorf("%w: jobs", responses.ErrMissingField)
+	}
+	var stale []responses.QueuedJob
+	deadline := time.Now().Add(-maxAge)
+	for _, j := range queue.Jobs {
+		if j.CreatedAt.Before(deadline) {
+			stale = append(stale, j)
+		}
+	}
+	return stale, nil
+}
+
+// clearStaleJobs 按 ClearStaleBefore 在打印小票前清空积压过久的队列，
+// 查询或清除失败、平台未返回任务明细时仅记录日志，不影响本次打印
+func (client *Client) clearStaleJobs(ctx context.Context, request *requests.CommonRequest) {
+	if client.ClearStaleBefore <= 0 || request.ApiName != "print" || request.BizContent["actWay"] == requests.ActWayVoice {
+		return
+	}
+	devName, _ := request.BizContent["devName"].(string)
+	queue, err := client.PrintQueue(ctx, devName)
+	if err == nil && queue.Jobs == nil {
+		err = fmt.Errorf("%w: jobs", responses.ErrMissingField)
+	}
+	if err == nil && (queue.Pending == 0 || time.Since(queue.Oldest()) < client.ClearStaleBefore) {
+		return
+	}
+	cleared := 0
+	if err == nil {
+		cleared, err = client.ClearPrintQueue(ctx, devName)
+	}
+	if client.Logger == nil || client.LogLevel == LogLevelOff {
+		return
+	}
+	if err != nil {
+		client.Logger.Warn("yxyiot clear stale jobs failed", "devName", devName, "error", err.Error())
+		return
+	}
+	if client.LogLevel != LogLevelError {
+		client.Logger.Info("yxyiot stale jobs cleared", "devName", devName, "pending", queue.Pending, "cleared", cleared, "oldest", queue.Oldest())
+	}
+}
@@ -0,0 +1,77 @@
+package yxyiot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bigrocs/yxyiot/requests"
+	"github.com/bigrocs/yxyiot/responses"
+)
+
+func TestStaleJobs(t *testing.T) {
+	client := NewClient()
+	var calls []string
+	old := time.Now().Add(-time.Hour).Format("2006-01-02 15:04:05")
+	recent := time.Now().Add(-time.Minute).Format("2006-01-02 15:04:05")
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, request *requests.CommonRequest, response *responses.CommonResponse) error {
+			calls = append(calls, request.ApiName)
+			reply := `{"code":0}`
+			if request.ApiName == "printQueue" {
+				reply = `{"code":0,"data":{"pending":3,"jobs":[
+					{"requestId":"r-1","createTime":"` + old + `"},
+					{"requestId":"r-2","createTime":"` + recent + `"},
+					{"requestId":"r-3","createTime":"` + old + `"}]}}`
+			}
+			response.SetHttpContent([]byte(reply), "string")
+			return nil
+		}
+	})
+	stale, err := client.StaleJobs(context.Background(), "bsj00576", 10*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stale) != 2 || stale[0].RequestId != "r-1" || stale[1].RequestId != "r-3" {
+		t.Fatalf("unexpected stale jobs: %+v", stale)
+	}
+	if len(calls) != 1 || calls[0] != "printQueue" {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+}
+
+func TestClearStaleBefore(t *testing.T) {
+	client := NewClient()
+	client.ClearStaleBefore = 10 * time.Minute
+	var calls []string
+	oldest := time.Now().Add(-time.Hour)
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, request *requests.CommonRequest, response *responses.CommonResponse) error {
+			calls = append(calls, request.ApiName)
+			reply := `{"code":0}`
+			switch request.ApiName {
+			case "printQueue":
+				reply = `{"code":0,"data":{"pending":2,"jobs":[
+					{"requestId":"r-1","createTime":"` + oldest.Format("2006-01-02 15:04:05") + `"},
+					{"requestId":"r-2","createTime":"` + time.Now().Format("2006-01-02 15:04:05") + `"}]}}`
+			case "clearQueue":
+				reply = `{"code":0,"data":{"cleared":2}}`
+			}
+			response.SetHttpContent([]byte(reply), "string")
+			return nil
+		}
+	})
+	if _, err := client.Print(context.Background(), requests.NewPrintRequest("bsj00576", "订单编号: 1200897812792015996<BR>")); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 3 || calls[0] != "printQueue" || calls[1] != "clearQueue" || calls[2] != "print" {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+	calls, oldest = nil, time.Now()
+	if _, err := client.Print(context.Background(), requests.NewPrintRequest("bsj00576", "订单编号: 1200897812792015997<BR>")); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 2 || calls[0] != "printQueue" || calls[1] != "print" {
+		t.Fatalf("fresh queue must not be cleared: %v", calls)
+	}
+}
@@ -0,0 +1,170 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 限流范围
+const (
+	ScopeApp    = "app"    // 按开发者 AppId
+	ScopeDevice = "device" // 按设备 devName
+)
+
+// LimitError 请求超出限流配置
+type LimitError struct {
+	Scope      string        // 限流范围
+	Key        string        // AppId 或 devName
+	RetryAfter time.Duration // 预计可重试的等待时间
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("yxyiot: rate limited, scope=%s key=%s retryAfter=%v", e.Scope, e.Key, e.RetryAfter)
+}
+
+// Limit 令牌桶配置
+type Limit struct {
+	Rate  float64 `json:"rate"`  // 每秒补充令牌数，小于等于 0 时不限流
+	Burst int     `json:"burst"` // 桶容量，小于 1 时按 1 处理
+}
+
+// bucket 令牌桶
+type bucket struct {
+	limit  Limit
+	tokens float64
+	last   time.Time
+}
+
+// newBucket 创建装满令牌的桶
+func newBucket(limit Limit, now time.Time) *bucket {
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	return &bucket{limit: limit, tokens: float64(limit.Burst), last: now}
+}
+
+// delay 补充令牌并返回获取一个令牌所需的等待时间
+func (b *bucket) delay(now time.Time) time.Duration {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.limit.Rate
+		if b.tokens > float64(b.limit.Burst) {
+			b.tokens = float64(b.limit.Burst)
+		}
+		b.last = now
+	}
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.limit.Rate * float64(time.Second))
+}
+
+// Limiter 按 AppId 及 devName 限流，避免单个门店耗尽整个账号的调用额度
+type Limiter struct {
+	App    Limit // AppId 默认限流配置
+	Device Limit // devName 默认限流配置
+	Wait   bool  // 为 true 时等待令牌（受上下文控制），否则立即返回 *LimitError
+
+	mu           sync.Mutex
+	appLimits    map[string]Limit
+	deviceLimits map[string]Limit
+	apps         map[string]*bucket
+	devices      map[string]*bucket
+}
+
+// New 创建限流器
+func New(app, device Limit, wait bool) *Limiter {
+	return &Limiter{
+		App:          app,
+		Device:       device,
+		Wait:         wait,
+		appLimits:    make(map[string]Limit),
+		deviceLimits: make(map[string]Limit),
+		apps:         make(map[string]*bucket),
+		devices:      make(map[string]*bucket),
+	}
+}
+
+// SetAppLimit 单独设置指定 AppId 的限流配置
+func (l *Limiter) SetAppLimit(appId string, limit Limit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.appLimits[appId] = limit
+	delete(l.apps, appId)
+}
+
+// SetDeviceLimit 单独设置指定设备的限流配置
+func (l *Limiter) SetDeviceLimit(devName string, limit Limit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.deviceLimits[devName] = limit
+	delete(l.devices, devName)
+}
+
+// bucketFor 获取限流桶，不限流时返回 nil，调用方需持有锁
+func bucketFor(buckets map[string]*bucket, limits map[string]Limit, def Limit, key string, now time.Time) *bucket {
+	if b, ok := buckets[key]; ok {
+		return b
+	}
+	limit, ok := limits[key]
+	if !ok {
+		limit = def
+	}
+	if limit.Rate <= 0 {
+		return nil
+	}
+	b := newBucket(limit, now)
+	buckets[key] = b
+	return b
+}
+
+// Take 为一次请求获取令牌，devName 为空时只按 AppId 限流
+func (l *Limiter) Take(ctx context.Context, appId, devName string) error {
+	now := time.Now()
+	l.mu.Lock()
+	var taken []*bucket
+	var wait time.Duration
+	var limited *LimitError
+	app := bucketFor(l.apps, l.appLimits, l.App, appId, now)
+	if app != nil {
+		if d := app.delay(now); d > wait {
+			wait = d
+			limited = &LimitError{Scope: ScopeApp, Key: appId, RetryAfter: d}
+		}
+		taken = append(taken, app)
+	}
+	if devName != "" {
+		if dev := bucketFor(l.devices, l.deviceLimits, l.Device, devName, now); dev != nil {
+			if d := dev.delay(now); d > wait {
+				wait = d
+				limited = &LimitError{Scope: ScopeDevice, Key: devName, RetryAfter: d}
+			}
+			taken = append(taken, dev)
+		}
+	}
+	if limited != nil && !l.Wait {
+		l.mu.Unlock()
+		return limited
+	}
+	for _, b := range taken {
+		b.tokens-- // 等待模式下预占令牌
+	}
+	l.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		for _, b := range taken {
+			b.tokens++
+		}
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,38 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFailFast(t *testing.T) {
+	l := New(Limit{Rate: 100, Burst: 10}, Limit{Rate: 1, Burst: 2}, false)
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := l.Take(ctx, "app", "bsj00575"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := l.Take(ctx, "app", "bsj00575")
+	var le *LimitError
+	if !errors.As(err, &le) || le.Scope != ScopeDevice || le.Key != "bsj00575" {
+		t.Fatalf("expected device limit error, got %v", err)
+	}
+	if err := l.Take(ctx, "app", "bsj00576"); err != nil {
+		t.Fatalf("other device should not be limited: %v", err)
+	}
+}
+
+func TestWaitCanceled(t *testing.T) {
+	l := New(Limit{Rate: 0.1, Burst: 1}, Limit{}, true)
+	if err := l.Take(context.Background(), "app", ""); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Take(ctx, "app", ""); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package registry
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bigrocs/yxyiot/requests"
+)
+
+// 设备能力
+const (
+	CapVoice         = "voice"          // 语音播报
+	CapPrint         = "print"          // 小票打印
+	CapPaperWidth    = "paper width"    // 纸宽
+	CapLogo          = "logo"           // 打印 LOGO
+	CapCut           = "cut"            // 自动切纸
+	CapContentLength = "content length" // 内容长度
+)
+
+// Capabilities 设备能力
+type Capabilities struct {
+	Voice            bool `json:"voice" yaml:"voice"`                       // 支持语音播报
+	Print            bool `json:"print" yaml:"print"`                       // 支持小票打印
+	PaperWidth       int  `json:"paperWidth" yaml:"paperWidth"`             // 打印纸宽度（毫米）
+	Logo             bool `json:"logo" yaml:"logo"`                         // 支持 <LOGO> 标签
+	Cut              bool `json:"cut" yaml:"cut"`                           // 支持 <CUT> 标签
+	MaxContentLength int  `json:"maxContentLength" yaml:"maxContentLength"` // 内容最大字符数，0 表示不限
+}
+
+// Capabilities 返回设备能力，未配置时按设备类型推断
+func (d *Device) Capabilities() Capabilities {
+	if d.Caps != nil {
+		c := *d.Caps
+		if c.PaperWidth == 0 {
+			c.PaperWidth = d.PaperWidth
+		}
+		return c
+	}
+	c := Capabilities{PaperWidth: d.PaperWidth}
+	switch d.Type {
+	case TypeSpeaker:
+		c.Voice = true
+	case TypePrinter:
+		c.Print, c.Logo, c.Cut = true, true, true
+	case TypeCombo:
+		c.Voice, c.Print, c.Logo, c.Cut = true, true, true, true
+	}
+	return c
+}
+
+// CapabilityError 请求超出设备能力
+type CapabilityError struct {
+	DevName    string
+	Capability string
+	Detail     string
+}
+
+func (e *CapabilityError) Error() string {
+	msg := fmt.Sprintf("registry: device %q does not support %s", e.DevName, e.Capability)
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	return msg
+}
+
+// Validate 按接口名称校验公共请求是否在设备能力范围内，客户端在发送每个请求前调用
+// play 需要语音播报能力，print 按执行方式需要语音播报或小票打印能力，其他接口不校验
+func Validate(d Device, request *requests.CommonRequest) error {
+	c := d.Capabilities()
+	text := func(key string) string {
+		s, _ := request.BizContent[key].(string)
+		return s
+	}
+	switch request.ApiName {
+	case "play":
+		if !c.Voice {
+			return &CapabilityError{DevName: d.Name, Capability: CapVoice}
+		}
+		return checkLength(d.Name, c, text("content"))
+	case "print":
+		if text("actWay") == requests.ActWayVoice {
+			if !c.Voice {
+				return &CapabilityError{DevName: d.Name, Capability: CapVoice}
+			}
+			return nil
+		}
+		if !c.Print {
+			return &CapabilityError{DevName: d.Name, Capability: CapPrint}
+		}
+		data := text("data")
+		if !c.Logo && strings.Contains(data, "<LOGO>") {
+			return &CapabilityError{DevName: d.Name, Capability: CapLogo}
+		}
+		if !c.Cut && strings.Contains(data, "<CUT>") {
+			return &CapabilityError{DevName: d.Name, Capability: CapCut}
+		}
+		return checkLength(d.Name, c, data)
+	}
+	return nil
+}
+
+// ValidatePlay 校验播报请求是否在设备能力范围内
+func ValidatePlay(d Device, request *requests.PlayRequest) error {
+	return Validate(d, request.CommonRequest())
+}
+
+// ValidatePrint 校验打印请求是否在设备能力范围内，排版纸宽不随请求发送，仅在此校验
+func ValidatePrint(d Device, request *requests.PrintRequest) error {
+	c := d.Capabilities()
+	if request.ActWay != requests.ActWayVoice && c.Print &&
+		request.PaperWidth > 0 && c.PaperWidth > 0 && request.PaperWidth > c.PaperWidth {
+		return &CapabilityError{DevName: d.Name, Capability: CapPaperWidth,
+			Detail: fmt.Sprintf("layout %dmm, paper %dmm", request.PaperWidth, c.PaperWidth)}
+	}
+	return Validate(d, request.CommonRequest())
+}
+
+// checkLength 校验内容长度
+func checkLength(devName string, c Capabilities, content string) error {
+	if n := utf8.RuneCountInString(content); c.MaxContentLength > 0 && n > c.MaxContentLength {
+		return &CapabilityError{DevName: devName, Capability: CapContentLength,
+			Detail: fmt.Sprintf("%d characters, max %d", n, c.MaxContentLength)}
+	}
+	return nil
+}
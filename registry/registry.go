@@ -0,0 +1,228 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 设备类型
+const (
+	TypeSpeaker = "speaker" // 云喇叭
+	TypePrinter = "printer" // 云打印机
+	TypeCombo   = "combo"   // 带播报功能的打印机
+)
+
+// Device 设备信息
+type Device struct {
+	Name       string   `json:"name" yaml:"name"`             // 平台设备名称 devName
+	Store      string   `json:"store" yaml:"store"`           // 门店编号
+	Aliases    []string `json:"aliases" yaml:"aliases"`       // 别名，如 "前台喇叭"
+	Type       string   `json:"type" yaml:"type"`             // 设备类型
+	PaperWidth int      `json:"paperWidth" yaml:"paperWidth"` // 打印纸宽度（毫米）
+	Groups     []string `json:"groups" yaml:"groups"`         // 分组标签
+
+	Caps *Capabilities `json:"capabilities,omitempty" yaml:"capabilities,omitempty"` // 设备能力，为空时按设备类型推断
+}
+
+// Is 判断设备是否属于指定类型，combo 同时属于 speaker 与 printer
+func (d *Device) Is(typ string) bool {
+	return d.Type == typ || (d.Type == TypeCombo && (typ == TypeSpeaker || typ == TypePrinter))
+}
+
+// Config 设备配置文件
+type Config struct {
+	Devices []Device `json:"devices" yaml:"devices"`
+}
+
+// Source 设备数据来源，可对接数据库或配置中心
+type Source interface {
+	Devices() ([]Device, error)
+}
+
+// NotFoundError 目标未匹配到任何设备
+type NotFoundError struct {
+	Target string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("registry: no device matches %q", e.Target)
+}
+
+// Registry 设备注册表，可按设备名称、别名、门店及分组查找设备
+type Registry struct {
+	mu      sync.RWMutex
+	devices map[string]Device
+	aliases map[string]string
+}
+
+// New 由设备列表创建注册表
+func New(devices []Device) (*Registry, error) {
+	r := &Registry{}
+	if err := r.Reload(devices); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// FromSource 由数据来源创建注册表
+func FromSource(src Source) (*Registry, error) {
+	devices, err := src.Devices()
+	if err != nil {
+		return nil, err
+	}
+	return New(devices)
+}
+
+// Load 解析设备配置，unmarshal 为空时按 JSON 解析，可传入 yaml.Unmarshal 解析 YAML
+func Load(data []byte, unmarshal func([]byte, interface{}) error) (*Registry, error) {
+	if unmarshal == nil {
+		unmarshal = json.Unmarshal
+	}
+	var cfg Config
+	if err := unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	return New(cfg.Devices)
+}
+
+// LoadFile 从文件加载设备配置
+func LoadFile(path string, unmarshal func([]byte, interface{}) error) (*Registry, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Load(data, unmarshal)
+}
+
+// Reload 以新的设备列表整体替换注册表内容
+func (r *Registry) Reload(devices []Device) error {
+	byName := make(map[string]Device, len(devices))
+	aliases := make(map[string]string)
+	for _, d := range devices {
+		if d.Name == "" {
+			return fmt.Errorf("registry: device without name")
+		}
+		if _, ok := byName[d.Name]; ok {
+			return fmt.Errorf("registry: duplicate device %q", d.Name)
+		}
+		switch d.Type {
+		case TypeSpeaker, TypePrinter, TypeCombo:
+		default:
+			return fmt.Errorf("registry: device %q has unknown type %q", d.Name, d.Type)
+		}
+		byName[d.Name] = d
+		for _, a := range d.Aliases {
+			if other, ok := aliases[a]; ok && other != d.Name {
+				return fmt.Errorf("registry: alias %q used by %q and %q", a, other, d.Name)
+			}
+			aliases[a] = d.Name
+		}
+	}
+	r.mu.Lock()
+	r.devices, r.aliases = byName, aliases
+	r.mu.Unlock()
+	return nil
+}
+
+// Device 按设备名称或别名查找设备
+func (r *Registry) Device(nameOrAlias string) (Device, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if d, ok := r.devices[nameOrAlias]; ok {
+		return d, true
+	}
+	if name, ok := r.aliases[nameOrAlias]; ok {
+		return r.devices[name], true
+	}
+	return Device{}, false
+}
+
+// Devices 列出所有设备，按名称排序
+func (r *Registry) Devices() []Device {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]Device, 0, len(r.devices))
+	for _, d := range r.devices {
+		list = append(list, d)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Store 列出门店下的设备
+func (r *Registry) Store(storeId string) []Device {
+	var list []Device
+	for _, d := range r.Devices() {
+		if d.Store == storeId {
+			list = append(list, d)
+		}
+	}
+	return list
+}
+
+// Groups 查询设备所属分组，可用作 policy.Engine.Groups
+func (r *Registry) Groups(devName string) []string {
+	d, ok := r.Device(devName)
+	if !ok {
+		return nil
+	}
+	return d.Groups
+}
+
+// Resolve 将目标解析为设备名称列表，目标格式：
+//
+//	设备名称或别名       bsj00575、前台喇叭
+//	store:<门店>[/<类型>] store:123、store:123/printer
+//	group:<分组>[/<类型>] group:mall-east、group:mall-east/speaker
+func (r *Registry) Resolve(target string) ([]string, error) {
+	kind, value := "", target
+	if i := strings.IndexByte(target, ':'); i > 0 {
+		kind, value = target[:i], target[i+1:]
+	}
+	typ := ""
+	if kind != "" {
+		if i := strings.LastIndexByte(value, '/'); i >= 0 {
+			value, typ = value[:i], value[i+1:]
+		}
+	}
+	var names []string
+	switch kind {
+	case "":
+		if d, ok := r.Device(value); ok {
+			names = append(names, d.Name)
+		}
+	case "store", "group":
+		for _, d := range r.Devices() {
+			if kind == "store" && d.Store != value {
+				continue
+			}
+			if kind == "group" && !contains(d.Groups, value) {
+				continue
+			}
+			if typ != "" && !d.Is(typ) {
+				continue
+			}
+			names = append(names, d.Name)
+		}
+	default:
+		return nil, fmt.Errorf("registry: unknown target kind %q", kind)
+	}
+	if len(names) == 0 {
+		return nil, &NotFoundError{Target: target}
+	}
+	return names, nil
+}
+
+// contains 判断切片中是否包含指定字符串
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package registry
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bigrocs/yxyiot/requests"
+)
+
+const testConfig = `{"devices":[
+	{"name":"bsj00575","store":"123","aliases":["前台喇叭"],"type":"speaker","groups":["mall-east"]},
+	{"name":"bsj00576","store":"123","type":"combo","paperWidth":58,"groups":["mall-east"]},
+	{"name":"bsj00577","store":"456","type":"printer","paperWidth":80}
+]}`
+
+func TestResolve(t *testing.T) {
+	r, err := Load([]byte(testConfig), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for target, want := range map[string][]string{
+		"前台喇叭":                    {"bsj00575"},
+		"bsj00577":                {"bsj00577"},
+		"store:123":               {"bsj00575", "bsj00576"},
+		"store:123/printer":       {"bsj00576"},
+		"group:mall-east/speaker": {"bsj00575", "bsj00576"},
+	} {
+		got, err := r.Resolve(target)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Fatalf("Resolve(%q) = %v, %v; want %v", target, got, err, want)
+		}
+	}
+	if _, err := r.Resolve("group:mall-west"); err == nil {
+		t.Fatal("expected not found error")
+	}
+	if _, err := Load([]byte(`{"devices":[{"name":"a","type":"speaker","aliases":["x"]},{"name":"b","type":"speaker","aliases":["x"]}]}`), nil); err == nil {
+		t.Fatal("expected duplicate alias error")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	speaker := Device{Name: "bsj00575", Type: TypeSpeaker}
+	if err := ValidatePrint(speaker, requests.NewPrintRequest("bsj00575", "订单编号: 1200897812792015996<BR>")); err == nil {
+		t.Fatal("expected print capability error")
+	}
+	if err := ValidatePlay(speaker, requests.NewPlayRequest("bsj00575", "张三收款成功3467.91元")); err != nil {
+		t.Fatal(err)
+	}
+	printer := Device{Name: "bsj00576", Type: TypePrinter, PaperWidth: 58, Caps: &Capabilities{Print: true, MaxContentLength: 10}}
+	wide := requests.NewPrintRequest("bsj00576", "<LOGO>")
+	wide.PaperWidth = 80
+	for _, r := range []*requests.PrintRequest{wide, requests.NewPrintRequest("bsj00576", "<CUT>"), requests.NewPrintRequest("bsj00576", "订单编号: 1200897812792015996")} {
+		if err := ValidatePrint(printer, r); err == nil {
+			t.Fatalf("expected capability error for %+v", r)
+		}
+	}
+	if err := ValidatePlay(printer, requests.NewPlayRequest("bsj00576", "收款成功")); err == nil {
+		t.Fatal("expected voice capability error")
+	}
+}
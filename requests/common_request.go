@@ -5,6 +5,7 @@ type CommonRequest struct {
 	Domain     string
 	ApiName    string
 	BizContent map[string]interface{}
+	BizKey     string // 业务去重键（如订单号），不发送到平台
 }
 
 // NewCommonRequest 创建新的公共连接
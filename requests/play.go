@@ -0,0 +1,45 @@
+package requests
+
+// PlayRequest 云播报请求
+type PlayRequest struct {
+	DevName       string `json:"devName"`          // 设备名称
+	BizType       string `json:"bizType"`          // 业务类型
+	Content       string `json:"content"`          // 播报内容
+	Money         string `json:"money"`            // 播报金额
+	BroadCastType string `json:"broadCastType"`    // 播报类型
+	RequestId     string `json:"requestId"`        // 请求ID，为空时自动生成
+	BizKey        string `json:"bizKey,omitempty"` // 业务去重键（如订单号），用于生成稳定的 requestId 及抑制重复发送
+}
+
+// NewPlayRequest 创建云播报请求
+func NewPlayRequest(devName, content string) (request *PlayRequest) {
+	request = &PlayRequest{
+		DevName: devName,
+		BizType: "2",
+		Content: content,
+	}
+	return
+}
+
+// CommonRequest 转换为公共请求
+func (r *PlayRequest) CommonRequest() *CommonRequest {
+	request := NewCommonRequest()
+	request.ApiName = "play"
+	request.BizContent = map[string]interface{}{
+		"devName": r.DevName,
+		"bizType": r.BizType,
+	}
+	setIfNotEmpty(request.BizContent, "content", r.Content)
+	setIfNotEmpty(request.BizContent, "money", r.Money)
+	setIfNotEmpty(request.BizContent, "broadCastType", r.BroadCastType)
+	setIfNotEmpty(request.BizContent, "requestId", r.RequestId)
+	request.BizKey = r.BizKey
+	return request
+}
+
+// setIfNotEmpty 参数不为空时写入
+func setIfNotEmpty(m map[string]interface{}, key, value string) {
+	if value != "" {
+		m[key] = value
+	}
+}
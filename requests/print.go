@@ -0,0 +1,44 @@
+package requests
+
+// 打印执行方式
+const (
+	ActWayPrint = "1" // 打印小票
+	ActWayVoice = "2" // 打印机播报
+)
+
+// PrintRequest 云打印请求
+type PrintRequest struct {
+	DevName   string `json:"devName"`          // 设备名称
+	ActWay    string `json:"actWay"`           // 执行方式，见 ActWayPrint、ActWayVoice
+	Data      string `json:"data"`             // 打印内容（actWay=1）
+	VoiceJson string `json:"voiceJson"`        // 播报内容（actWay=2）
+	RequestId string `json:"requestId"`        // 请求ID，为空时自动生成
+	BizKey    string `json:"bizKey,omitempty"` // 业务去重键（如订单号），用于生成稳定的 requestId 及抑制重复发送
+
+	PaperWidth int `json:"paperWidth,omitempty"` // 排版所用纸宽（毫米），不发送至平台，仅用于校验设备能力
+}
+
+// NewPrintRequest 创建云打印请求
+func NewPrintRequest(devName, data string) (request *PrintRequest) {
+	request = &PrintRequest{
+		DevName: devName,
+		ActWay:  ActWayPrint,
+		Data:    data,
+	}
+	return
+}
+
+// CommonRequest 转换为公共请求
+func (r *PrintRequest) CommonRequest() *CommonRequest {
+	request := NewCommonRequest()
+	request.ApiName = "print"
+	request.BizContent = map[string]interface{}{
+		"devName": r.DevName,
+		"actWay":  r.ActWay,
+	}
+	setIfNotEmpty(request.BizContent, "data", r.Data)
+	setIfNotEmpty(request.BizContent, "voiceJson", r.VoiceJson)
+	setIfNotEmpty(request.BizContent, "requestId", r.RequestId)
+	request.BizKey = r.BizKey
+	return request
+}
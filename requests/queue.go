@@ -0,0 +1,51 @@
+package requests
+
+// PrintQueueRequest 打印机待打印任务查询请求
+type PrintQueueRequest struct {
+	DevName   string `json:"devName"`   // 设备名称
+	RequestId string `json:"requestId"` // 请求ID，为空时自动生成
+}
+
+// NewPrintQueueRequest 创建待打印任务查询请求
+func NewPrintQueueRequest(devName string) (request *PrintQueueRequest) {
+	request = &PrintQueueRequest{
+		DevName: devName,
+	}
+	return
+}
+
+// CommonRequest 转换为公共请求
+func (r *PrintQueueRequest) CommonRequest() *CommonRequest {
+	request := NewCommonRequest()
+	request.ApiName = "printQueue"
+	request.BizContent = map[string]interface{}{
+		"devName": r.DevName,
+	}
+	setIfNotEmpty(request.BizContent, "requestId", r.RequestId)
+	return request
+}
+
+// ClearQueueRequest 清空打印机待打印任务请求
+type ClearQueueRequest struct {
+	DevName   string `json:"devName"`   // 设备名称
+	RequestId string `json:"requestId"` // 请求ID，为空时自动生成
+}
+
+// NewClearQueueRequest 创建清空待打印任务请求
+func NewClearQueueRequest(devName string) (request *ClearQueueRequest) {
+	request = &ClearQueueRequest{
+		DevName: devName,
+	}
+	return
+}
+
+// CommonRequest 转换为公共请求
+func (r *ClearQueueRequest) CommonRequest() *CommonRequest {
+	request := NewCommonRequest()
+	request.ApiName = "clearQueue"
+	request.BizContent = map[string]interface{}{
+		"devName": r.DevName,
+	}
+	setIfNotEmpty(request.BizContent, "requestId", r.RequestId)
+	return request
+}
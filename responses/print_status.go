@@ -0,0 +1,72 @@
+package responses
+
+import (
+	"fmt"
+	"time"
+)
+
+// 打印任务状态
+const (
+	PrintStateQueued   = "queued"   // 已提交，等待打印
+	PrintStatePrinting = "printing" // 打印中
+	PrintStatePrinted  = "printed"  // 已出票
+	PrintStateFailed   = "failed"   // 打印失败
+	PrintStateUnknown  = "unknown"  // 无法识别的状态
+)
+
+// PrintStateCodes 平台打印状态码（printStatus 字段）与打印任务状态的对应关系，需按平台文档配置，默认为空
+// 未配置的状态码解析为 PrintStateUnknown，原始状态码保留在 PrintJobStatus.Code 中
+var PrintStateCodes = map[string]string{}
+
+// PrintJobStatus 打印任务状态
+type PrintJobStatus struct {
+	RequestId string    `json:"requestId"` // 打印请求的 requestId
+	DevName   string    `json:"devName"`   // 打印机设备
+	State     string    `json:"state"`     // 任务状态，见 PrintStateQueued 等
+	Code      string    `json:"code"`      // 平台原始状态码
+	Reason    string    `json:"reason"`    // 失败原因
+	UpdatedAt time.Time `json:"updatedAt"` // 状态更新时间
+}
+
+// Done 任务是否已结束（已出票或失败）
+func (s *PrintJobStatus) Done() bool {
+	return s.State == PrintStatePrinted || s.State == PrintStateFailed
+}
+
+// ParsePrintJobStatus 由推送通知参数解析打印任务状态
+// printStatus 为必填字段，缺失时返回 ErrMissingField，状态码按 PrintStateCodes 转换
+func ParsePrintJobStatus(data map[string]interface{}) (*PrintJobStatus, error) {
+	d := &decoder{m: data}
+	s := &PrintJobStatus{Code: d.str("printStatus")}
+	if d.has("printRequestId") {
+		s.RequestId = d.str("printRequestId")
+	}
+	if d.has("devName") {
+		s.DevName = d.str("devName")
+	}
+	if d.has("reason") {
+		s.Reason = d.str("reason")
+	}
+	if d.has("updateTime") {
+		s.UpdatedAt = d.datetime("updateTime")
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	s.State = PrintStateUnknown
+	if state, ok := PrintStateCodes[s.Code]; ok {
+		s.State = state
+	}
+	return s, nil
+}
+
+// RequestId 返回请求携带的 requestId，未携带时为空
+func (res *CommonResponse) RequestId() string {
+	if res.Request == nil {
+		return ""
+	}
+	if v, ok := res.Request.BizContent["requestId"]; ok {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
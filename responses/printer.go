@@ -0,0 +1,128 @@
+package responses
+
+import (
+	"strconv"
+	"strings"
+)
+
+// PrinterCondition 打印机状况标志，为 SDK 内部定义，与平台状态码的取值无关
+type PrinterCondition int
+
+// 打印机状况
+const (
+	PrinterPaperOut    PrinterCondition = 1 << iota // 缺纸
+	PrinterPaperLow                                 // 纸将尽
+	PrinterCoverOpen                                // 开盖
+	PrinterOverheated                               // 打印头过热
+	PrinterCutterError                              // 切刀异常
+	PrinterOffline                                  // 离线
+)
+
+// PrinterStatusBits 平台以数字返回状态时各状态位对应的状况，需按平台状态码文档配置，默认为空
+// 未配置时数字状态均视为无法识别，数字中含未配置的状态位时同样视为无法识别
+var PrinterStatusBits = map[int]PrinterCondition{}
+
+// PrinterStatusNames 平台以文字返回状态时的取值与状况的对应关系（不区分大小写），需按平台文档配置，默认为空
+// 表示正常的取值配置为 0
+var PrinterStatusNames = map[string]PrinterCondition{}
+
+// PrinterState 解析后的打印机状态
+type PrinterState struct {
+	Code        string `json:"code"`        // 平台原始状态
+	Known       bool   `json:"known"`       // 原始状态是否可按 PrinterStatusBits 或 PrinterStatusNames 完整识别
+	Online      bool   `json:"online"`      // 在线
+	PaperOut    bool   `json:"paperOut"`    // 缺纸
+	PaperLow    bool   `json:"paperLow"`    // 纸将尽
+	CoverOpen   bool   `json:"coverOpen"`   // 开盖
+	Overheated  bool   `json:"overheated"`  // 打印头过热
+	CutterError bool   `json:"cutterError"` // 切刀异常
+}
+
+// printerConditions 状态描述，按严重程度排序
+var printerConditions = []struct {
+	get    func(s *PrinterState) bool
+	zh, en string
+}{
+	{func(s *PrinterState) bool { return !s.Online }, "离线", "offline"},
+	{func(s *PrinterState) bool { return s.PaperOut }, "缺纸", "out of paper"},
+	{func(s *PrinterState) bool { return s.CoverOpen }, "开盖", "cover open"},
+	{func(s *PrinterState) bool { return s.CutterError }, "切刀异常", "cutter error"},
+	{func(s *PrinterState) bool { return s.Overheated }, "打印头过热", "print head overheated"},
+	{func(s *PrinterState) bool { return s.PaperLow }, "纸将尽", "paper low"},
+}
+
+// DecodePrinterStatus 按 PrinterStatusBits 或 PrinterStatusNames 解析平台返回的打印机状态，
+// 文字状态可用 , 或 | 分隔多个；可用于状态查询结果及推送通知，无法完整识别时 Known 为 false
+func DecodePrinterStatus(v interface{}) PrinterState {
+	code := strings.TrimSpace(toString(v))
+	s := PrinterState{Code: code}
+	var cond PrinterCondition
+	known := code != ""
+	if n, err := strconv.Atoi(code); err == nil {
+		mask := 0
+		for bit, c := range PrinterStatusBits {
+			mask |= bit
+			if n&bit != 0 {
+				cond |= c
+			}
+		}
+		known = len(PrinterStatusBits) > 0 && n&^mask == 0
+	} else {
+		for _, part := range strings.FieldsFunc(code, func(r rune) bool { return r == ',' || r == '|' }) {
+			c, ok := PrinterStatusNames[strings.ToLower(strings.TrimSpace(part))]
+			known = known && ok
+			cond |= c
+		}
+	}
+	s.Known = known
+	s.Online = cond&PrinterOffline == 0
+	s.PaperOut = cond&PrinterPaperOut != 0
+	s.PaperLow = cond&PrinterPaperLow != 0
+	s.CoverOpen = cond&PrinterCoverOpen != 0
+	s.Overheated = cond&PrinterOverheated != 0
+	s.CutterError = cond&PrinterCutterError != 0
+	return s
+}
+
+// OK 打印机是否可正常出票（纸将尽仍可打印），状态无法识别时返回 false
+func (s *PrinterState) OK() bool {
+	return s.Known && s.Online && !s.PaperOut && !s.CoverOpen && !s.Overheated && !s.CutterError
+}
+
+// Describe 中文描述，如 "缺纸、开盖"
+func (s *PrinterState) Describe() string {
+	return s.describe(true)
+}
+
+// DescribeEn 英文描述，如 "out of paper, cover open"
+func (s *PrinterState) DescribeEn() string {
+	return s.describe(false)
+}
+
+// describe 拼接状态描述
+func (s *PrinterState) describe(zh bool) string {
+	var list []string
+	for _, c := range printerConditions {
+		if !c.get(s) {
+			continue
+		}
+		if zh {
+			list = append(list, c.zh)
+		} else {
+			list = append(list, c.en)
+		}
+	}
+	switch {
+	case len(list) > 0 && zh:
+		return strings.Join(list, "、")
+	case len(list) > 0:
+		return strings.Join(list, ", ")
+	case !s.Known && zh:
+		return strings.TrimSpace("未知状态 " + s.Code)
+	case !s.Known:
+		return strings.TrimSpace("unknown status " + s.Code)
+	case zh:
+		return "正常"
+	}
+	return "normal"
+}
@@ -0,0 +1,35 @@
+package responses
+
+import "testing"
+
+func TestDecodePrinterStatus(t *testing.T) {
+	defer func(bits map[int]PrinterCondition, names map[string]PrinterCondition) {
+		PrinterStatusBits, PrinterStatusNames = bits, names
+	}(PrinterStatusBits, PrinterStatusNames)
+	if s := DecodePrinterStatus("4"); s.Known || s.OK() || s.Describe() != "未知状态 4" {
+		t.Fatalf("unconfigured code must be unknown: %+v", s)
+	}
+	PrinterStatusBits = map[int]PrinterCondition{1: PrinterPaperOut, 4: PrinterCoverOpen}
+	PrinterStatusNames = map[string]PrinterCondition{"normal": 0, "paper_low": PrinterPaperLow, "overheat": PrinterOverheated}
+
+	s := DecodePrinterStatus(float64(5))
+	if !s.Known || !s.Online || !s.PaperOut || !s.CoverOpen || s.OK() {
+		t.Fatalf("unexpected state: %+v", s)
+	}
+	if s.Describe() != "缺纸、开盖" || s.DescribeEn() != "out of paper, cover open" {
+		t.Fatalf("unexpected description: %q %q", s.Describe(), s.DescribeEn())
+	}
+	if s = DecodePrinterStatus("3"); s.Known || !s.PaperOut {
+		t.Fatalf("bits outside the table must be unknown: %+v", s)
+	}
+	s = DecodePrinterStatus("PAPER_LOW|overheat")
+	if !s.Known || !s.PaperLow || !s.Overheated || s.OK() || s.DescribeEn() != "print head overheated, paper low" {
+		t.Fatalf("unexpected state: %+v", s)
+	}
+	if s = DecodePrinterStatus("0"); !s.OK() || s.Describe() != "正常" {
+		t.Fatalf("unexpected state: %+v", s)
+	}
+	if s = DecodePrinterStatus("jammed"); s.Known || s.DescribeEn() != "unknown status jammed" {
+		t.Fatalf("unexpected state: %+v", s)
+	}
+}
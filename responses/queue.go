@@ -0,0 +1,81 @@
+package responses
+
+import (
+	"fmt"
+	"time"
+)
+
+// QueuedJob 待打印任务
+type QueuedJob struct {
+	RequestId string    `json:"requestId"` // 打印请求的 requestId
+	CreatedAt time.Time `json:"createdAt"` // 提交时间
+}
+
+// PrintQueue 打印机待打印任务
+type PrintQueue struct {
+	DevName string      `json:"devName"` // 设备名称
+	Pending int         `json:"pending"` // 待打印任务数
+	Jobs    []QueuedJob `json:"jobs"`    // 待打印任务明细，平台未返回时为 nil
+}
+
+// Oldest 最早一条待打印任务的提交时间，无任务明细时为零值
+func (q *PrintQueue) Oldest() time.Time {
+	var oldest time.Time
+	for _, j := range q.Jobs {
+		if oldest.IsZero() || j.CreatedAt.Before(oldest) {
+			oldest = j.CreatedAt
+		}
+	}
+	return oldest
+}
+
+// GetPrintQueue 解析待打印任务查询结果，pending 为必填字段，jobs 存在时每项需含 requestId 与 createTime
+func (res *CommonResponse) GetPrintQueue() (*PrintQueue, error) {
+	data, err := res.GetData()
+	if err != nil {
+		return nil, err
+	}
+	d := &decoder{m: data}
+	q := &PrintQueue{Pending: int(d.integer("pending"))}
+	if d.has("devName") {
+		q.DevName = d.str("devName")
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	if !d.has("jobs") {
+		return q, nil
+	}
+	items, ok := data["jobs"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("yxyiot: response field jobs has unexpected value %v", data["jobs"])
+	}
+	q.Jobs = make([]QueuedJob, 0, len(items))
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("yxyiot: response field jobs has unexpected item %v", item)
+		}
+		d := &decoder{m: m}
+		j := QueuedJob{RequestId: d.str("requestId"), CreatedAt: d.datetime("createTime")}
+		if d.err != nil {
+			return nil, d.err
+		}
+		q.Jobs = append(q.Jobs, j)
+	}
+	return q, nil
+}
+
+// GetClearedCount 解析清空队列结果，返回被清除的任务数，平台未返回 cleared 字段时为 -1
+func (res *CommonResponse) GetClearedCount() (int, error) {
+	data, err := res.GetData()
+	if err != nil {
+		return 0, err
+	}
+	d := &decoder{m: data}
+	if !d.has("cleared") {
+		return -1, nil
+	}
+	n := d.integer("cleared")
+	return int(n), d.err
+}
@@ -16,6 +16,7 @@ package responses
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"github.com/clbanning/mxj"
 
@@ -74,3 +75,59 @@ func (res *CommonResponse) SetHttpContent(httpContent []byte, dataType string) {
 		res.json = string(res.httpContent)
 	}
 }
+
+// APIError 平台返回的业务错误
+type APIError struct {
+	Code    string
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("yxyiot: api error code=%s msg=%s", e.Code, e.Message)
+}
+
+// SuccessCodes 平台表示成功的返回码
+var SuccessCodes = []string{"0", "200"}
+
+// DeviceUnavailableCodes 平台表示设备离线或设备不存在的返回码，需按平台错误码文档配置，默认为空
+// 仅这些业务错误计入设备熔断及触发门店备用路径，签名错误、参数错误等其他业务错误不影响设备状态
+var DeviceUnavailableCodes []string
+
+// DeviceUnavailable 业务错误是否表示设备离线或不存在
+func (e *APIError) DeviceUnavailable() bool {
+	for _, c := range DeviceUnavailableCodes {
+		if e.Code == c {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAPIError 获取平台返回的业务错误，请求成功或无返回内容时返回 nil
+func (res *CommonResponse) GetAPIError() *APIError {
+	if res.json == "" {
+		return nil
+	}
+	m, err := res.GetHttpContentMap()
+	if err != nil {
+		return &APIError{Code: "", Message: "invalid response: " + res.json}
+	}
+	code, ok := m["code"]
+	if !ok {
+		return nil
+	}
+	c := fmt.Sprint(code)
+	for _, s := range SuccessCodes {
+		if c == s {
+			return nil
+		}
+	}
+	e := &APIError{Code: c}
+	for _, k := range []string{"msg", "message"} {
+		if v, ok := m[k]; ok {
+			e.Message = fmt.Sprint(v)
+			break
+		}
+	}
+	return e
+}
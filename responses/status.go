@@ -0,0 +1,49 @@
+package responses
+
+import "time"
+
+// DeviceStatus 设备状态
+type DeviceStatus struct {
+	DevName       string    `json:"devName"`       // 设备名称
+	Online        bool      `json:"online"`        // 是否在线
+	Signal        int       `json:"signal"`        // 信号强度
+	LastHeartbeat time.Time `json:"lastHeartbeat"` // 最近一次心跳时间
+	PaperOut      bool      `json:"paperOut"`      // 打印机缺纸
+	CoverOpen     bool      `json:"coverOpen"`     // 打印机开盖
+	PrinterStatus string    `json:"printerStatus"` // 打印机原始状态码，云喇叭为空
+
+	Printer *PrinterState `json:"printer,omitempty"` // 由 PrinterStatus 解析的打印机状态，云喇叭为空；不影响平台返回的 PaperOut、CoverOpen
+}
+
+// ParseDeviceStatus 由推送通知参数解析设备状态
+// online 为必填字段，缺失时返回 ErrMissingField；打印机相关字段仅在存在时解析
+func ParseDeviceStatus(data map[string]interface{}) (*DeviceStatus, error) {
+	d := &decoder{m: data}
+	s := &DeviceStatus{Online: d.boolean("online")}
+	if d.has("devName") {
+		s.DevName = d.str("devName")
+	}
+	if d.has("signal") {
+		s.Signal = int(d.integer("signal"))
+	}
+	if d.has("lastHeartbeat") {
+		s.LastHeartbeat = d.datetime("lastHeartbeat")
+	}
+	if d.has("paperOut") {
+		s.PaperOut = d.boolean("paperOut")
+	}
+	if d.has("coverOpen") {
+		s.CoverOpen = d.boolean("coverOpen")
+	}
+	if d.has("printerStatus") {
+		s.PrinterStatus = d.str("printerStatus")
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	if s.PrinterStatus != "" {
+		p := DecodePrinterStatus(s.PrinterStatus)
+		s.Printer = &p
+	}
+	return s, nil
+}
@@ -0,0 +1,21 @@
+package responses
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseDeviceStatus(t *testing.T) {
+	s, err := ParseDeviceStatus(map[string]interface{}{"online": "1", "signal": "27", "lastHeartbeat": float64(1760000000000)})
+	if err != nil || !s.Online || s.Signal != 27 || !s.LastHeartbeat.Equal(time.Unix(1760000000, 0)) || s.Printer != nil {
+		t.Fatalf("unexpected speaker status: %+v %v", s, err)
+	}
+	s, err = ParseDeviceStatus(map[string]interface{}{"online": float64(0), "paperOut": true, "coverOpen": "0", "printerStatus": "4"})
+	if err != nil || s.Online || !s.PaperOut || s.CoverOpen || s.Printer == nil || s.Printer.Known {
+		t.Fatalf("unexpected printer status: %+v %v", s, err)
+	}
+	if _, err = ParseDeviceStatus(map[string]interface{}{"onlineStatus": float64(1)}); !errors.Is(err, ErrMissingField) {
+		t.Fatalf("expected missing field error, got %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package responses
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrMissingField 返回内容缺少必填字段，通常表示接口地址或字段名与平台文档不符
+var ErrMissingField = errors.New("yxyiot: response field missing")
+
+// GetData 获取返回内容中的 data 字段，data 不是对象时返回空
+func (res *CommonResponse) GetData() (map[string]interface{}, error) {
+	m, err := res.GetHttpContentMap()
+	if err != nil {
+		return nil, err
+	}
+	data, _ := m["data"].(map[string]interface{})
+	return data, nil
+}
+
+// toString 转换为字符串
+func toString(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// decoder 按单一字段名严格解析返回内容，字段缺失或取值无法识别时记录首个错误，
+// 以免字段名或取值格式与平台不符时静默返回零值
+type decoder struct {
+	m   map[string]interface{}
+	err error
+}
+
+// has 字段是否存在且不为 null，用于可选字段
+func (d *decoder) has(key string) bool {
+	v, ok := d.m[key]
+	return ok && v != nil
+}
+
+// value 取必填字段
+func (d *decoder) value(key string) interface{} {
+	if d.err != nil {
+		return nil
+	}
+	if !d.has(key) {
+		d.err = fmt.Errorf("%w: %s", ErrMissingField, key)
+		return nil
+	}
+	return d.m[key]
+}
+
+// fail 记录字段取值错误
+func (d *decoder) fail(key string, v interface{}) {
+	if d.err == nil {
+		d.err = fmt.Errorf("yxyiot: response field %s has unexpected value %v", key, v)
+	}
+}
+
+// str 取字符串字段，数字按原样格式化
+func (d *decoder) str(key string) string {
+	return toString(d.value(key))
+}
+
+// integer 取整数字段，支持数字及数字字符串
+func (d *decoder) integer(key string) int64 {
+	v := d.value(key)
+	if v == nil {
+		return 0
+	}
+	if f, ok := v.(float64); ok {
+		return int64(f)
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(toString(v)), 10, 64)
+	if err != nil {
+		d.fail(key, v)
+	}
+	return n
+}
+
+// boolean 取布尔字段，支持 true/false 及 1/0
+func (d *decoder) boolean(key string) bool {
+	v := d.value(key)
+	if b, ok := v.(bool); ok || v == nil {
+		return b
+	}
+	switch toString(v) {
+	case "1", "true":
+		return true
+	case "0", "false":
+		return false
+	}
+	d.fail(key, v)
+	return false
+}
+
+// datetime 取时间字段，支持毫秒、秒时间戳及 "yyyy-MM-dd HH:mm:ss" 格式
+func (d *decoder) datetime(key string) time.Time {
+	v := d.value(key)
+	if v == nil {
+		return time.Time{}
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", toString(v), time.Local); err == nil {
+		return t
+	}
+	n := d.integer(key)
+	switch {
+	case n > 1e12:
+		return time.Unix(0, n*int64(time.Millisecond))
+	case n > 0:
+		return time.Unix(n, 0)
+	}
+	d.fail(key, v)
+	return time.Time{}
+}
@@ -0,0 +1,39 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+)
+
+// Tracer 链路追踪接口，可适配 OpenTelemetry 等实现
+type Tracer interface {
+	// Start 开始一个子跨度，返回携带该跨度的上下文
+	Start(ctx context.Context, name string) (context.Context, Span)
+	// Inject 将上下文中的追踪信息写入请求头，用于跨服务传播
+	Inject(ctx context.Context, header http.Header)
+}
+
+// Span 追踪跨度
+type Span interface {
+	// SetAttributes 设置属性，kv 为交替的键值对
+	SetAttributes(kv ...interface{})
+	// End 结束跨度，err 不为空时标记为失败
+	End(err error)
+}
+
+// Nop 不做任何记录的追踪实现
+var Nop Tracer = nopTracer{}
+
+type nopTracer struct{}
+
+func (nopTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	return ctx, nopSpan{}
+}
+
+func (nopTracer) Inject(ctx context.Context, header http.Header) {}
+
+type nopSpan struct{}
+
+func (nopSpan) SetAttributes(kv ...interface{}) {}
+
+func (nopSpan) End(err error) {}
@@ -2,6 +2,7 @@ package util
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
@@ -21,29 +22,54 @@ import (
 
 //HTTPGet get 请求
 func HTTPGet(uri string) ([]byte, error) {
-	response, err := http.Get(uri)
+	return HTTPGetWithContext(context.Background(), uri, nil)
+}
+
+//HTTPGetWithContext 携带上下文及请求头的 get 请求
+func HTTPGetWithContext(ctx context.Context, uri string, header http.Header) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", uri, nil)
 	if err != nil {
-		return nil, err
+		return nil, RedactError(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	response, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, RedactError(err)
 	}
 
 	defer response.Body.Close()
 	if response.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("http get error : uri=%v , statusCode=%v", uri, response.StatusCode)
+		return nil, fmt.Errorf("http get error : uri=%v , statusCode=%v", RedactURL(uri), response.StatusCode)
 	}
 	return ioutil.ReadAll(response.Body)
 }
 
 //PostForm form  数据请求
 func PostForm(url string, obj string) ([]byte, error) {
+	return PostFormWithContext(context.Background(), url, obj, nil)
+}
+
+//PostFormWithContext 携带上下文及请求头的 form 数据请求
+func PostFormWithContext(ctx context.Context, url string, obj string, header http.Header) ([]byte, error) {
 	reader := strings.NewReader(obj)
-	response, err := http.Post(url, "application/x-www-form-urlencoded", reader)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, reader)
 	if err != nil {
-		return nil, err
+		return nil, RedactError(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	response, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, RedactError(err)
 	}
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("http get error : url=%v , statusCode=%v", url, response.StatusCode)
+		return nil, fmt.Errorf("http get error : url=%v , statusCode=%v", RedactURL(url), response.StatusCode)
 	}
 	return ioutil.ReadAll(response.Body)
 }
@@ -232,7 +258,7 @@ func httpWithTLS(rootCa string, certData string, password string) (client *http.
 	if certData != "" {
 		certD, err = base64.StdEncoding.DecodeString(certData)
 		if err != nil {
-			return nil, fmt.Errorf("certData 商家秘钥文件转码错误", err)
+			return nil, fmt.Errorf("certData 商家秘钥文件转码错误, error=%v", err)
 		}
 	}
 	cert, err = pkcs12ToPem(certD, password)
@@ -0,0 +1,116 @@
+package util
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// RedactedValue 脱敏后的占位值
+const RedactedValue = "***"
+
+// SensitiveKeys 需要脱敏的参数名（不区分大小写）
+var SensitiveKeys = []string{"token", "appSecret", "devKey"}
+
+// PersonalKeys 可能包含付款人姓名等个人信息的参数名
+var PersonalKeys = []string{"content", "data", "voiceJson", "payerName"}
+
+// IsSensitiveKey 判断参数名是否需要脱敏
+func IsSensitiveKey(key string) bool {
+	for _, k := range SensitiveKeys {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPersonalKey 判断参数是否可能包含个人信息
+func IsPersonalKey(key string) bool {
+	for _, k := range PersonalKeys {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactParams 返回脱敏后的参数副本
+func RedactParams(params map[string]interface{}) map[string]interface{} {
+	m := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		if IsSensitiveKey(k) {
+			m[k] = RedactedValue
+			continue
+		}
+		m[k] = v
+	}
+	return m
+}
+
+// RedactURL 将链接中的敏感参数替换为占位值
+func RedactURL(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.RawQuery == "" {
+		return uri
+	}
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return uri
+	}
+	changed := false
+	for k := range query {
+		if IsSensitiveKey(k) {
+			query.Set(k, RedactedValue)
+			changed = true
+		}
+	}
+	if !changed {
+		return uri
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// RedactString 将字符串中出现的密钥原文及其 URL 编码替换为占位值
+func RedactString(s string, secrets ...string) string {
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		s = strings.Replace(s, secret, RedactedValue, -1)
+		if escaped := url.QueryEscape(secret); escaped != secret {
+			s = strings.Replace(s, escaped, RedactedValue, -1)
+		}
+	}
+	return s
+}
+
+// redactedError 脱敏后的错误
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+
+func (e *redactedError) Unwrap() error { return e.err }
+
+// RedactError 返回不包含敏感参数及密钥原文的错误
+func RedactError(err error, secrets ...string) error {
+	if err == nil {
+		return nil
+	}
+	if ue, ok := err.(*url.Error); ok {
+		return &url.Error{
+			Op:  ue.Op,
+			URL: RedactString(RedactURL(ue.URL), secrets...),
+			Err: RedactError(ue.Err, secrets...),
+		}
+	}
+	msg := RedactString(err.Error(), secrets...)
+	if msg == err.Error() {
+		return err
+	}
+	return &redactedError{msg: msg, err: RedactError(errors.Unwrap(err), secrets...)}
+}
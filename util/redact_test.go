@@ -0,0 +1,30 @@
+package util
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRedactURL(t *testing.T) {
+	uri := RedactURL("https://ioe.car900.com/v1/openApi/dev/controlDevice.json?appId=a1&token=ABCDEF&content=hi")
+	if strings.Contains(uri, "ABCDEF") {
+		t.Fatalf("token not redacted: %s", uri)
+	}
+	if !strings.Contains(uri, "appId=a1") {
+		t.Fatalf("unexpected redaction: %s", uri)
+	}
+}
+
+func TestRedactError(t *testing.T) {
+	err := &url.Error{
+		Op:  "Get",
+		URL: "https://ioe.car900.com/x?token=ABCDEF",
+		Err: errors.New("dial secret-value failed"),
+	}
+	msg := RedactError(err, "secret-value").Error()
+	if strings.Contains(msg, "ABCDEF") || strings.Contains(msg, "secret-value") {
+		t.Fatalf("secret leaked: %s", msg)
+	}
+}
@@ -58,13 +58,13 @@ func PrivateEncrypt(encryptedKey string, ca string, certData string, password st
 	if ca != "" {
 		certD, err = ioutil.ReadFile(ca)
 		if err != nil {
-			return nil, fmt.Errorf("unable to find cert path=%s, error=%v", err)
+			return nil, fmt.Errorf("unable to find cert path=%s, error=%v", ca, err)
 		}
 	}
 	if certData != "" {
 		certD, err = base64.StdEncoding.DecodeString(certData)
 		if err != nil {
-			return nil, fmt.Errorf("certData 商家秘钥文件转码错误", err)
+			return nil, fmt.Errorf("certData 商家秘钥文件转码错误, error=%v", err)
 		}
 	}
 	privateKey, _, err := pkcs12.Decode(certD, password)
@@ -155,13 +155,13 @@ func Sign(params map[string]interface{}, ca string, certData string, password st
 	if ca != "" {
 		certD, err = ioutil.ReadFile(ca)
 		if err != nil {
-			return "", fmt.Errorf("unable to find cert path=%s, error=%v", err)
+			return "", fmt.Errorf("unable to find cert path=%s, error=%v", ca, err)
 		}
 	}
 	if certData != "" {
 		certD, err = base64.StdEncoding.DecodeString(certData)
 		if err != nil {
-			return "", fmt.Errorf("certData 商家秘钥文件转码错误", err)
+			return "", fmt.Errorf("certData 商家秘钥文件转码错误, error=%v", err)
 		}
 	}
 	privateKey, _, err := pkcs12.Decode(certD, password)